@@ -338,6 +338,12 @@ var resourceTypeToPrefixes map[resource.Type][]string = func() map[resource.Type
 	return ret
 }()
 
+func init() {
+	for typ, prefixes := range resourceTypeToPrefixes {
+		resource.RegisterPrefixes(typ, prefixes...)
+	}
+}
+
 // RegisterPrefixToResourceInfo is called from various packages to register
 // which prefixes belong to them, what types those represent, and any
 // domain and subtype information. This lets the subtypes stay in different
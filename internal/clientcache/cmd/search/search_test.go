@@ -171,8 +171,8 @@ func TestSearch(t *testing.T) {
 		{Id: "alt_1234567890", Value: "value1", DestinationId: "ttcp_1234567890"},
 		{Id: "alt_0987654321", Name: "value2", DestinationId: "ttcp_0987654321"},
 	}, []*targets.Target{
-		{Id: "ttcp_1234567890", Name: "name1", Description: "description1"},
-		{Id: "ttcp_0987654321", Name: "name2", Description: "description2"},
+		{Id: "ttcp_1234567890", Name: "name1", Description: "description1", Address: "address1"},
+		{Id: "ttcp_0987654321", Name: "name2", Description: "description2", Address: "address2"},
 	}, []*sessions.Session{
 		{Id: "sess_1234567890", TargetId: "ttcp_1234567890", Status: "pending"},
 		{Id: "sess_0987654321", TargetId: "ttcp_0987654321", Status: "pending"},
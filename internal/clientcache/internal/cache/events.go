@@ -0,0 +1,72 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/boundary/internal/event"
+)
+
+// TargetsRefreshedEvent describes a refresh of a user's cached targets.
+type TargetsRefreshedEvent struct {
+	UserId string
+	// Added is the number of targets cached by the refresh which were not
+	// cached before it.
+	Added int
+	// Updated is the number of previously cached targets which were changed
+	// by the refresh.
+	Updated int
+	// Removed is the number of previously cached targets which were removed
+	// by the refresh.
+	Removed int
+}
+
+// StaleTargetsListedEvent describes the listing of a user's cached targets
+// when they were last refreshed longer ago than the repository's stale
+// threshold.
+type StaleTargetsListedEvent struct {
+	UserId          string
+	LastRefreshTime time.Time
+	Age             time.Duration
+}
+
+// EventEmitter is notified of events that happen to the cached resources.
+type EventEmitter interface {
+	TargetsRefreshed(context.Context, *TargetsRefreshedEvent)
+	StaleTargetsListed(context.Context, *StaleTargetsListedEvent)
+}
+
+// noopEventEmitter is the EventEmitter used when none is provided.
+type noopEventEmitter struct{}
+
+func (noopEventEmitter) TargetsRefreshed(context.Context, *TargetsRefreshedEvent)     {}
+func (noopEventEmitter) StaleTargetsListed(context.Context, *StaleTargetsListedEvent) {}
+
+// ObservationEventEmitter is an EventEmitter which writes the events it is
+// notified of as observation events.
+type ObservationEventEmitter struct{}
+
+// TargetsRefreshed satisfies the EventEmitter interface.
+func (ObservationEventEmitter) TargetsRefreshed(ctx context.Context, e *TargetsRefreshedEvent) {
+	const op = "cache.(ObservationEventEmitter).TargetsRefreshed"
+	if e == nil {
+		return
+	}
+	if err := event.WriteObservation(ctx, op, event.WithDetails("user_id", e.UserId, "added", e.Added, "updated", e.Updated, "removed", e.Removed)); err != nil {
+		event.WriteError(ctx, op, err)
+	}
+}
+
+// StaleTargetsListed satisfies the EventEmitter interface.
+func (ObservationEventEmitter) StaleTargetsListed(ctx context.Context, e *StaleTargetsListedEvent) {
+	const op = "cache.(ObservationEventEmitter).StaleTargetsListed"
+	if e == nil {
+		return
+	}
+	if err := event.WriteObservation(ctx, op, event.WithDetails("user_id", e.UserId, "last_refresh_time", e.LastRefreshTime, "age", e.Age.String())); err != nil {
+		event.WriteError(ctx, op, err)
+	}
+}
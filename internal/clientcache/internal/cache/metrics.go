@@ -0,0 +1,31 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package cache
+
+import (
+	"time"
+
+	"github.com/hashicorp/boundary/internal/types/resource"
+)
+
+// Metrics is notified of the queries the repository makes against the cached
+// resources so they can be recorded, e.g. as prometheus metrics.
+type Metrics interface {
+	// ObserveQuery is called once the repository completes the operation, such
+	// as "list", "query" or "refresh", on the cached resources of the provided
+	// type with how long it took and the error it returned, if any.
+	ObserveQuery(typ resource.Type, op string, d time.Duration, err error)
+}
+
+// noopMetrics is the Metrics used when none is provided.
+type noopMetrics struct{}
+
+func (noopMetrics) ObserveQuery(resource.Type, string, time.Duration, error) {}
+
+// observeQuery reports the operation on the cached resources of the provided
+// type to the repository's metrics. It is meant to be deferred at the start of
+// the operation with a pointer to the error it returns.
+func (r *Repository) observeQuery(typ resource.Type, op string, start time.Time, err *error) {
+	r.metrics.ObserveQuery(typ, op, time.Since(start), *err)
+}
@@ -4,18 +4,39 @@
 package cache
 
 import (
+	"time"
+
+	"github.com/hashicorp/boundary/globals"
 	"github.com/hashicorp/go-dbw"
 )
 
 type options struct {
-	withUpdateLastAccessedTime bool
-	withDbType                 dbw.DbType
-	withAuthTokenId            string
-	withUserId                 string
-	withAliasRetrievalFunc     AliasRetrievalFunc
-	withTargetRetrievalFunc    TargetRetrievalFunc
-	withSessionRetrievalFunc   SessionRetrievalFunc
-	withIgnoreSearchStaleness  bool
+	withUpdateLastAccessedTime     bool
+	withDbType                     dbw.DbType
+	withAuthTokenId                string
+	withUserId                     string
+	withAliasRetrievalFunc         AliasRetrievalFunc
+	withTargetRetrievalFunc        TargetRetrievalFunc
+	withSessionRetrievalFunc       SessionRetrievalFunc
+	withHostRetrievalFunc          HostRetrievalFunc
+	withStorageBucketRetrievalFunc StorageBucketRetrievalFunc
+	withIgnoreSearchStaleness      bool
+	withLimit                      int
+	withOrder                      string
+	withSortColumn                 string
+	withSortDirection              SortDirection
+	withMaxAge                     time.Duration
+	withClock                      func() time.Time
+	withEventEmitter               EventEmitter
+	withStaleThreshold             time.Duration
+	withRemoveOrphanedUsers        bool
+	withScopeId                    string
+	withIncrementalRefresh         bool
+	withTargetType                 globals.Subtype
+	withActiveOnly                 bool
+	withQueryTimeout               time.Duration
+	withFields                     []string
+	withMetrics                    Metrics
 }
 
 // Option - how options are passed as args
@@ -86,6 +107,23 @@ func WithSessionRetrievalFunc(fn SessionRetrievalFunc) Option {
 	}
 }
 
+// WithHostRetrievalFunc provides an option for specifying a hostRetrievalFunc
+func WithHostRetrievalFunc(fn HostRetrievalFunc) Option {
+	return func(o *options) error {
+		o.withHostRetrievalFunc = fn
+		return nil
+	}
+}
+
+// WithStorageBucketRetrievalFunc provides an option for specifying a
+// storageBucketRetrievalFunc
+func WithStorageBucketRetrievalFunc(fn StorageBucketRetrievalFunc) Option {
+	return func(o *options) error {
+		o.withStorageBucketRetrievalFunc = fn
+		return nil
+	}
+}
+
 // WithIgnoreSearchStaleness provides an option for ignoring the resource
 // staleness when performing a search.
 func WithIgnoreSearchStaleness(b bool) Option {
@@ -94,3 +132,153 @@ func WithIgnoreSearchStaleness(b bool) Option {
 		return nil
 	}
 }
+
+// WithLimit provides an option for limiting the number of results returned
+// from a search. A limit less than or equal to 0 means no limit.
+func WithLimit(l int) Option {
+	return func(o *options) error {
+		o.withLimit = l
+		return nil
+	}
+}
+
+// withOrder provides an option for ordering the results returned from a search
+func withOrder(order string) Option {
+	return func(o *options) error {
+		o.withOrder = order
+		return nil
+	}
+}
+
+// SortDirection is the direction in which search results are sorted.
+type SortDirection string
+
+const (
+	AscendingSortDirection  SortDirection = "asc"
+	DescendingSortDirection SortDirection = "desc"
+)
+
+// WithSort provides an option for sorting the results returned from a search
+// by the provided column in the provided direction. If no direction is
+// provided the results are sorted in ascending order.
+func WithSort(column string, direction SortDirection) Option {
+	return func(o *options) error {
+		o.withSortColumn = column
+		o.withSortDirection = direction
+		return nil
+	}
+}
+
+// WithMaxAge provides an option for only returning resources which were last
+// refreshed from boundary within the provided duration.
+func WithMaxAge(d time.Duration) Option {
+	return func(o *options) error {
+		o.withMaxAge = d
+		return nil
+	}
+}
+
+// withClock provides an option for specifying the function used to get the
+// current time.
+func withClock(fn func() time.Time) Option {
+	return func(o *options) error {
+		o.withClock = fn
+		return nil
+	}
+}
+
+// WithEventEmitter provides an option for specifying the EventEmitter notified
+// of refreshes of cached resources and of stale resources being served.
+func WithEventEmitter(e EventEmitter) Option {
+	return func(o *options) error {
+		o.withEventEmitter = e
+		return nil
+	}
+}
+
+// WithMetrics provides an option for specifying the Metrics notified of the
+// queries made against the cached resources.
+func WithMetrics(m Metrics) Option {
+	return func(o *options) error {
+		o.withMetrics = m
+		return nil
+	}
+}
+
+// WithStaleThreshold provides an option for specifying how long after their
+// last refresh cached resources are considered stale when being listed. A
+// zero duration means listed resources are never considered stale.
+func WithStaleThreshold(d time.Duration) Option {
+	return func(o *options) error {
+		o.withStaleThreshold = d
+		return nil
+	}
+}
+
+// WithRemoveOrphanedUsers provides an option for removing the auth tokens which
+// are expired or no longer referenced and, along with them, the cached
+// resources of the users left without an auth token.
+func WithRemoveOrphanedUsers(b bool) Option {
+	return func(o *options) error {
+		o.withRemoveOrphanedUsers = b
+		return nil
+	}
+}
+
+// WithScopeId provides an option for only returning resources which belong to
+// the scope with the provided id. An empty id returns resources from all
+// scopes.
+func WithScopeId(id string) Option {
+	return func(o *options) error {
+		o.withScopeId = id
+		return nil
+	}
+}
+
+// WithIncrementalRefresh provides an option for refreshing the full set of
+// cached resources by only writing the resources which were added, changed or
+// removed instead of replacing all of them.
+func WithIncrementalRefresh(b bool) Option {
+	return func(o *options) error {
+		o.withIncrementalRefresh = b
+		return nil
+	}
+}
+
+// WithTargetType provides an option for only returning targets of the provided
+// subtype, such as tcp or ssh.
+func WithTargetType(t globals.Subtype) Option {
+	return func(o *options) error {
+		o.withTargetType = t
+		return nil
+	}
+}
+
+// WithActiveOnly provides an option for only returning sessions which are
+// not canceling or terminated.
+func WithActiveOnly(b bool) Option {
+	return func(o *options) error {
+		o.withActiveOnly = b
+		return nil
+	}
+}
+
+// WithQueryTimeout provides an option for bounding the time the repository
+// spends on its database queries when the caller's context has no deadline.
+// Zero means the queries are not bounded.
+func WithQueryTimeout(d time.Duration) Option {
+	return func(o *options) error {
+		o.withQueryTimeout = d
+		return nil
+	}
+}
+
+// WithFields provides an option for only reading and populating the provided
+// fields of the resources returned from a search, leaving the others set to
+// their zero value. No fields means the full resources are returned.
+func WithFields(f ...string) Option {
+	return func(o *options) error {
+		o.withFields = f
+		return nil
+	}
+}
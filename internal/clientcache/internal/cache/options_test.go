@@ -6,9 +6,12 @@ package cache
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/boundary/api/aliases"
+	"github.com/hashicorp/boundary/api/hosts"
 	"github.com/hashicorp/boundary/api/sessions"
+	"github.com/hashicorp/boundary/api/storagebuckets"
 	"github.com/hashicorp/boundary/api/targets"
 	"github.com/hashicorp/go-dbw"
 	"github.com/stretchr/testify/assert"
@@ -86,6 +89,32 @@ func Test_GetOpts(t *testing.T) {
 		testOpts := getDefaultOptions()
 		assert.Equal(t, opts, testOpts)
 	})
+	t.Run("WithHostRetrievalFunc", func(t *testing.T) {
+		var f HostRetrievalFunc = func(ctx context.Context, addr, authTok string, refreshTok RefreshTokenValue) ([]*hosts.Host, []string, RefreshTokenValue, error) {
+			return nil, nil, "", nil
+		}
+		opts, err := getOpts(WithHostRetrievalFunc(f))
+		require.NoError(t, err)
+
+		assert.NotNil(t, opts.withHostRetrievalFunc)
+		opts.withHostRetrievalFunc = nil
+
+		testOpts := getDefaultOptions()
+		assert.Equal(t, opts, testOpts)
+	})
+	t.Run("WithStorageBucketRetrievalFunc", func(t *testing.T) {
+		var f StorageBucketRetrievalFunc = func(ctx context.Context, addr, authTok string, refreshTok RefreshTokenValue) ([]*storagebuckets.StorageBucket, []string, RefreshTokenValue, error) {
+			return nil, nil, "", nil
+		}
+		opts, err := getOpts(WithStorageBucketRetrievalFunc(f))
+		require.NoError(t, err)
+
+		assert.NotNil(t, opts.withStorageBucketRetrievalFunc)
+		opts.withStorageBucketRetrievalFunc = nil
+
+		testOpts := getDefaultOptions()
+		assert.Equal(t, opts, testOpts)
+	})
 	t.Run("withIgnoreSearchStaleness", func(t *testing.T) {
 		opts, err := getOpts(WithIgnoreSearchStaleness(true))
 		require.NoError(t, err)
@@ -93,4 +122,117 @@ func Test_GetOpts(t *testing.T) {
 		testOpts.withIgnoreSearchStaleness = true
 		assert.Equal(t, opts, testOpts)
 	})
+	t.Run("WithLimit", func(t *testing.T) {
+		opts, err := getOpts(WithLimit(5))
+		require.NoError(t, err)
+		testOpts := getDefaultOptions()
+		testOpts.withLimit = 5
+		assert.Equal(t, opts, testOpts)
+	})
+	t.Run("withOrder", func(t *testing.T) {
+		opts, err := getOpts(withOrder("id asc"))
+		require.NoError(t, err)
+		testOpts := getDefaultOptions()
+		testOpts.withOrder = "id asc"
+		assert.Equal(t, opts, testOpts)
+	})
+	t.Run("WithSort", func(t *testing.T) {
+		opts, err := getOpts(WithSort("name", DescendingSortDirection))
+		require.NoError(t, err)
+		testOpts := getDefaultOptions()
+		testOpts.withSortColumn = "name"
+		testOpts.withSortDirection = DescendingSortDirection
+		assert.Equal(t, opts, testOpts)
+	})
+	t.Run("WithMaxAge", func(t *testing.T) {
+		opts, err := getOpts(WithMaxAge(time.Hour))
+		require.NoError(t, err)
+		testOpts := getDefaultOptions()
+		testOpts.withMaxAge = time.Hour
+		assert.Equal(t, opts, testOpts)
+	})
+	t.Run("withClock", func(t *testing.T) {
+		now := time.Now()
+		opts, err := getOpts(withClock(func() time.Time { return now }))
+		require.NoError(t, err)
+
+		require.NotNil(t, opts.withClock)
+		assert.Equal(t, now, opts.withClock())
+		opts.withClock = nil
+
+		testOpts := getDefaultOptions()
+		assert.Equal(t, opts, testOpts)
+	})
+	t.Run("WithEventEmitter", func(t *testing.T) {
+		e := &testEventEmitter{}
+		opts, err := getOpts(WithEventEmitter(e))
+		require.NoError(t, err)
+		testOpts := getDefaultOptions()
+		testOpts.withEventEmitter = e
+		assert.Equal(t, opts, testOpts)
+	})
+	t.Run("WithStaleThreshold", func(t *testing.T) {
+		opts, err := getOpts(WithStaleThreshold(time.Hour))
+		require.NoError(t, err)
+		testOpts := getDefaultOptions()
+		testOpts.withStaleThreshold = time.Hour
+		assert.Equal(t, opts, testOpts)
+	})
+	t.Run("WithRemoveOrphanedUsers", func(t *testing.T) {
+		opts, err := getOpts(WithRemoveOrphanedUsers(true))
+		require.NoError(t, err)
+		testOpts := getDefaultOptions()
+		testOpts.withRemoveOrphanedUsers = true
+		assert.Equal(t, opts, testOpts)
+	})
+	t.Run("WithScopeId", func(t *testing.T) {
+		opts, err := getOpts(WithScopeId("p_123"))
+		require.NoError(t, err)
+		testOpts := getDefaultOptions()
+		testOpts.withScopeId = "p_123"
+		assert.Equal(t, opts, testOpts)
+	})
+	t.Run("WithIncrementalRefresh", func(t *testing.T) {
+		opts, err := getOpts(WithIncrementalRefresh(true))
+		require.NoError(t, err)
+		testOpts := getDefaultOptions()
+		testOpts.withIncrementalRefresh = true
+		assert.Equal(t, opts, testOpts)
+	})
+	t.Run("WithTargetType", func(t *testing.T) {
+		opts, err := getOpts(WithTargetType("ssh"))
+		require.NoError(t, err)
+		testOpts := getDefaultOptions()
+		testOpts.withTargetType = "ssh"
+		assert.Equal(t, opts, testOpts)
+	})
+	t.Run("WithActiveOnly", func(t *testing.T) {
+		opts, err := getOpts(WithActiveOnly(true))
+		require.NoError(t, err)
+		testOpts := getDefaultOptions()
+		testOpts.withActiveOnly = true
+		assert.Equal(t, opts, testOpts)
+	})
+	t.Run("WithQueryTimeout", func(t *testing.T) {
+		opts, err := getOpts(WithQueryTimeout(time.Second))
+		require.NoError(t, err)
+		testOpts := getDefaultOptions()
+		testOpts.withQueryTimeout = time.Second
+		assert.Equal(t, opts, testOpts)
+	})
+	t.Run("WithMetrics", func(t *testing.T) {
+		m := noopMetrics{}
+		opts, err := getOpts(WithMetrics(m))
+		require.NoError(t, err)
+		testOpts := getDefaultOptions()
+		testOpts.withMetrics = m
+		assert.Equal(t, opts, testOpts)
+	})
+	t.Run("WithFields", func(t *testing.T) {
+		opts, err := getOpts(WithFields("id", "name"))
+		require.NoError(t, err)
+		testOpts := getDefaultOptions()
+		testOpts.withFields = []string{"id", "name"}
+		assert.Equal(t, opts, testOpts)
+	})
 }
@@ -75,7 +75,7 @@ func (r *RefreshService) cleanAndPickAuthTokens(ctx context.Context, u *user) (m
 				event.WriteSysEvent(ctx, op, "Removed keyring token since the keyring contents have changed since being cached", "keyring", kt.KeyringType, "token name", kt.TokenName, "old auth token id", kt.AuthTokenId)
 				// delete the keyring token if the auth token in the keyring
 				// has changed since it was stored in the cache.
-				if err := r.repo.deleteKeyringToken(ctx, *kt); err != nil {
+				if err := r.repo.DeleteKeyringToken(ctx, *kt); err != nil {
 					return nil, errors.Wrap(ctx, err, op, errors.WithMsg("for user %q, auth token %q", u.Id, t.Id))
 				}
 			case at != nil:
@@ -83,7 +83,7 @@ func (r *RefreshService) cleanAndPickAuthTokens(ctx context.Context, u *user) (m
 				var apiErr *api.Error
 				switch {
 				case err != nil && (api.ErrUnauthorized.Is(err) || api.ErrNotFound.Is(err)):
-					if err := r.repo.deleteKeyringToken(ctx, *kt); err != nil {
+					if err := r.repo.DeleteKeyringToken(ctx, *kt); err != nil {
 						return nil, errors.Wrap(ctx, err, op, errors.WithMsg("for user %q, auth token %q", u.Id, t.Id))
 					}
 					event.WriteSysEvent(ctx, op, "Removed auth token from cache because it was not found to be valid in boundary", "auth token id", at.Id)
@@ -223,7 +223,7 @@ func (r *RefreshService) RefreshForSearch(ctx context.Context, authTokenid strin
 				args = append(args, "target staleness", time.Since(rtv.UpdateTime))
 			}
 			r.logger.Debug("refreshing targets before performing search", args...)
-			if err := r.repo.refreshTargets(ctx, u, tokens, opt...); err != nil {
+			if _, err := r.repo.refreshTargets(ctx, u, tokens, opt...); err != nil && !stderrors.Is(err, ErrSkippedInvalidIds) {
 				return errors.Wrap(ctx, err, op, errors.WithoutEvent())
 			}
 		}
@@ -253,8 +253,9 @@ func (r *RefreshService) RefreshForSearch(ctx context.Context, authTokenid strin
 // have a refresh token or which do not have any resources in the cache yet. It
 // then attempts to read those user's resources from boundary and updates the
 // cache with the values retrieved there. Refresh accepts the options
-// WithTargetRetrievalFunc and WithSessionRetrievalFunc which overwrites the
-// default functions used to retrieve those resources from boundary.
+// WithTargetRetrievalFunc, WithSessionRetrievalFunc, WithHostRetrievalFunc and
+// WithStorageBucketRetrievalFunc which overwrite the default functions used to
+// retrieve those resources from boundary.
 func (r *RefreshService) Refresh(ctx context.Context, opt ...Option) error {
 	const op = "cache.(RefreshService).Refresh"
 	if err := r.repo.cleanExpiredOrOrphanedAuthTokens(ctx); err != nil {
@@ -286,12 +287,26 @@ func (r *RefreshService) Refresh(ctx context.Context, opt ...Option) error {
 		if err := r.repo.refreshAliases(ctx, u, tokens, opt...); err != nil {
 			retErr = stderrors.Join(retErr, errors.Wrap(ctx, err, op, errors.WithMsg(fmt.Sprintf("for user id %s", u.Id))))
 		}
-		if err := r.repo.refreshTargets(ctx, u, tokens, opt...); err != nil {
-			retErr = stderrors.Join(retErr, errors.Wrap(ctx, err, op, errors.WithMsg(fmt.Sprintf("for user id %s", u.Id))))
+		if _, err := r.repo.refreshTargets(ctx, u, tokens, opt...); err != nil {
+			switch {
+			case stderrors.Is(err, ErrSkippedInvalidIds):
+				// The rest of the targets were still cached and the skipped
+				// ids are reported in the cache's status, so this isn't
+				// treated as a failed refresh.
+				r.logger.Warn("skipped targets with invalid ids", "user", u.Id, "error", err)
+			default:
+				retErr = stderrors.Join(retErr, errors.Wrap(ctx, err, op, errors.WithMsg(fmt.Sprintf("for user id %s", u.Id))))
+			}
 		}
 		if err := r.repo.refreshSessions(ctx, u, tokens, opt...); err != nil {
 			retErr = stderrors.Join(retErr, errors.Wrap(ctx, err, op, errors.WithMsg(fmt.Sprintf("for user id %s", u.Id))))
 		}
+		if err := r.repo.refreshHosts(ctx, u, tokens, opt...); err != nil {
+			retErr = stderrors.Join(retErr, errors.Wrap(ctx, err, op, errors.WithMsg(fmt.Sprintf("for user id %s", u.Id))))
+		}
+		if err := r.repo.refreshStorageBuckets(ctx, u, tokens, opt...); err != nil {
+			retErr = stderrors.Join(retErr, errors.Wrap(ctx, err, op, errors.WithMsg(fmt.Sprintf("for user id %s", u.Id))))
+		}
 
 	}
 	return retErr
@@ -360,6 +375,20 @@ func (r *RefreshService) RecheckCachingSupport(ctx context.Context, opt ...Optio
 			}
 			retErr = stderrors.Join(retErr, errors.Wrap(ctx, err, op, errors.WithMsg(fmt.Sprintf("for user id %s", u.Id))))
 		}
+		if err := r.repo.checkCachingHosts(ctx, u, tokens, opt...); err != nil {
+			if err == ErrRefreshNotSupported {
+				// This is expected so no need to propagate the error up
+				continue
+			}
+			retErr = stderrors.Join(retErr, errors.Wrap(ctx, err, op, errors.WithMsg(fmt.Sprintf("for user id %s", u.Id))))
+		}
+		if err := r.repo.checkCachingStorageBuckets(ctx, u, tokens, opt...); err != nil {
+			if err == ErrRefreshNotSupported {
+				// This is expected so no need to propagate the error up
+				continue
+			}
+			retErr = stderrors.Join(retErr, errors.Wrap(ctx, err, op, errors.WithMsg(fmt.Sprintf("for user id %s", u.Id))))
+		}
 
 	}
 	return retErr
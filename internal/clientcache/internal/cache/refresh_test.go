@@ -15,7 +15,9 @@ import (
 	"github.com/hashicorp/boundary/api"
 	"github.com/hashicorp/boundary/api/aliases"
 	"github.com/hashicorp/boundary/api/authtokens"
+	"github.com/hashicorp/boundary/api/hosts"
 	"github.com/hashicorp/boundary/api/sessions"
+	"github.com/hashicorp/boundary/api/storagebuckets"
 	"github.com/hashicorp/boundary/api/targets"
 	"github.com/hashicorp/boundary/internal/clientcache/internal/db"
 	"github.com/hashicorp/go-hclog"
@@ -369,6 +371,8 @@ func TestRefreshForSearch(t *testing.T) {
 		}
 		opts := []Option{
 			WithAliasRetrievalFunc(testStaticResourceRetrievalFunc[*aliases.Alias](t, nil, nil)),
+			WithHostRetrievalFunc(testStaticResourceRetrievalFunc[*hosts.Host](t, nil, nil)),
+			WithStorageBucketRetrievalFunc(testStaticResourceRetrievalFunc[*storagebuckets.StorageBucket](t, nil, nil)),
 			WithSessionRetrievalFunc(testStaticResourceRetrievalFunc[*sessions.Session](t, nil, nil)),
 			WithTargetRetrievalFunc(testStaticResourceRetrievalFunc[*targets.Target](t,
 				[][]*targets.Target{
@@ -421,6 +425,8 @@ func TestRefreshForSearch(t *testing.T) {
 		}
 		opts := []Option{
 			WithAliasRetrievalFunc(testStaticResourceRetrievalFunc[*aliases.Alias](t, nil, nil)),
+			WithHostRetrievalFunc(testStaticResourceRetrievalFunc[*hosts.Host](t, nil, nil)),
+			WithStorageBucketRetrievalFunc(testStaticResourceRetrievalFunc[*storagebuckets.StorageBucket](t, nil, nil)),
 			WithSessionRetrievalFunc(testStaticResourceRetrievalFunc[*sessions.Session](t, nil, nil)),
 			WithTargetRetrievalFunc(testStaticResourceRetrievalFunc[*targets.Target](t,
 				[][]*targets.Target{
@@ -476,6 +482,8 @@ func TestRefreshForSearch(t *testing.T) {
 		// Get the first set of resources, but no refresh tokens
 		err = rs.Refresh(ctx,
 			WithAliasRetrievalFunc(testStaticResourceRetrievalFunc[*aliases.Alias](t, nil, nil)),
+			WithHostRetrievalFunc(testStaticResourceRetrievalFunc[*hosts.Host](t, nil, nil)),
+			WithStorageBucketRetrievalFunc(testStaticResourceRetrievalFunc[*storagebuckets.StorageBucket](t, nil, nil)),
 			WithSessionRetrievalFunc(testNoRefreshRetrievalFunc[*sessions.Session](t)),
 			WithTargetRetrievalFunc(testNoRefreshRetrievalFunc[*targets.Target](t)))
 		assert.ErrorContains(t, err, ErrRefreshNotSupported.Error())
@@ -489,12 +497,16 @@ func TestRefreshForSearch(t *testing.T) {
 		// any more.
 		err = rs.Refresh(ctx,
 			WithAliasRetrievalFunc(testNoRefreshRetrievalFunc[*aliases.Alias](t)),
+			WithHostRetrievalFunc(testStaticResourceRetrievalFunc[*hosts.Host](t, nil, nil)),
+			WithStorageBucketRetrievalFunc(testStaticResourceRetrievalFunc[*storagebuckets.StorageBucket](t, nil, nil)),
 			WithSessionRetrievalFunc(testNoRefreshRetrievalFunc[*sessions.Session](t)),
 			WithTargetRetrievalFunc(testNoRefreshRetrievalFunc[*targets.Target](t)))
 		assert.Nil(t, err)
 
 		err = rs.RecheckCachingSupport(ctx,
 			WithAliasRetrievalFunc(testNoRefreshRetrievalFunc[*aliases.Alias](t)),
+			WithHostRetrievalFunc(testStaticResourceRetrievalFunc[*hosts.Host](t, nil, nil)),
+			WithStorageBucketRetrievalFunc(testStaticResourceRetrievalFunc[*storagebuckets.StorageBucket](t, nil, nil)),
 			WithSessionRetrievalFunc(testNoRefreshRetrievalFunc[*sessions.Session](t)),
 			WithTargetRetrievalFunc(testNoRefreshRetrievalFunc[*targets.Target](t)))
 		assert.Nil(t, err)
@@ -507,6 +519,8 @@ func TestRefreshForSearch(t *testing.T) {
 		// the resources starting to be cached.
 		err = rs.RecheckCachingSupport(ctx,
 			WithAliasRetrievalFunc(testStaticResourceRetrievalFunc[*aliases.Alias](t, nil, nil)),
+			WithHostRetrievalFunc(testStaticResourceRetrievalFunc[*hosts.Host](t, nil, nil)),
+			WithStorageBucketRetrievalFunc(testStaticResourceRetrievalFunc[*storagebuckets.StorageBucket](t, nil, nil)),
 			WithSessionRetrievalFunc(testStaticResourceRetrievalFunc[*sessions.Session](t, nil, nil)),
 			WithTargetRetrievalFunc(testStaticResourceRetrievalFunc[*targets.Target](t, [][]*targets.Target{retTargets}, [][]string{{}})))
 		assert.Nil(t, err, err)
@@ -534,6 +548,8 @@ func TestRefreshForSearch(t *testing.T) {
 		opts := []Option{
 			WithAliasRetrievalFunc(testStaticResourceRetrievalFunc[*aliases.Alias](t, nil, nil)),
 			WithTargetRetrievalFunc(testStaticResourceRetrievalFunc[*targets.Target](t, nil, nil)),
+			WithHostRetrievalFunc(testStaticResourceRetrievalFunc[*hosts.Host](t, nil, nil)),
+			WithStorageBucketRetrievalFunc(testStaticResourceRetrievalFunc[*storagebuckets.StorageBucket](t, nil, nil)),
 			WithSessionRetrievalFunc(testStaticResourceRetrievalFunc[*sessions.Session](t,
 				[][]*sessions.Session{
 					retSess[:3],
@@ -583,6 +599,8 @@ func TestRefreshForSearch(t *testing.T) {
 		opts := []Option{
 			WithAliasRetrievalFunc(testStaticResourceRetrievalFunc[*aliases.Alias](t, nil, nil)),
 			WithTargetRetrievalFunc(testStaticResourceRetrievalFunc[*targets.Target](t, nil, nil)),
+			WithHostRetrievalFunc(testStaticResourceRetrievalFunc[*hosts.Host](t, nil, nil)),
+			WithStorageBucketRetrievalFunc(testStaticResourceRetrievalFunc[*storagebuckets.StorageBucket](t, nil, nil)),
 			WithSessionRetrievalFunc(testStaticResourceRetrievalFunc[*sessions.Session](t,
 				[][]*sessions.Session{
 					retSess[:3],
@@ -635,6 +653,8 @@ func TestRefreshForSearch(t *testing.T) {
 			alias("4"),
 		}
 		opts := []Option{
+			WithHostRetrievalFunc(testStaticResourceRetrievalFunc[*hosts.Host](t, nil, nil)),
+			WithStorageBucketRetrievalFunc(testStaticResourceRetrievalFunc[*storagebuckets.StorageBucket](t, nil, nil)),
 			WithSessionRetrievalFunc(testStaticResourceRetrievalFunc[*sessions.Session](t, nil, nil)),
 			WithTargetRetrievalFunc(testStaticResourceRetrievalFunc[*targets.Target](t, nil, nil)),
 			WithAliasRetrievalFunc(testStaticResourceRetrievalFunc[*aliases.Alias](t,
@@ -684,6 +704,8 @@ func TestRefreshForSearch(t *testing.T) {
 			alias("4"),
 		}
 		opts := []Option{
+			WithHostRetrievalFunc(testStaticResourceRetrievalFunc[*hosts.Host](t, nil, nil)),
+			WithStorageBucketRetrievalFunc(testStaticResourceRetrievalFunc[*storagebuckets.StorageBucket](t, nil, nil)),
 			WithSessionRetrievalFunc(testStaticResourceRetrievalFunc[*sessions.Session](t, nil, nil)),
 			WithTargetRetrievalFunc(testStaticResourceRetrievalFunc[*targets.Target](t, nil, nil)),
 			WithAliasRetrievalFunc(testStaticResourceRetrievalFunc[*aliases.Alias](t,
@@ -757,6 +779,8 @@ func TestRefresh(t *testing.T) {
 		}
 		opts := []Option{
 			WithAliasRetrievalFunc(testStaticResourceRetrievalFunc[*aliases.Alias](t, nil, nil)),
+			WithHostRetrievalFunc(testStaticResourceRetrievalFunc[*hosts.Host](t, nil, nil)),
+			WithStorageBucketRetrievalFunc(testStaticResourceRetrievalFunc[*storagebuckets.StorageBucket](t, nil, nil)),
 			WithSessionRetrievalFunc(testStaticResourceRetrievalFunc[*sessions.Session](t, nil, nil)),
 			WithTargetRetrievalFunc(testStaticResourceRetrievalFunc[*targets.Target](t,
 				[][]*targets.Target{
@@ -782,6 +806,39 @@ func TestRefresh(t *testing.T) {
 		assert.ElementsMatch(t, retTargets[2:], cachedTargets)
 	})
 
+	t.Run("skipped target ids", func(t *testing.T) {
+		s, err := db.Open(ctx)
+		require.NoError(t, err)
+		r, err := NewRepository(ctx, s, &sync.Map{}, mapBasedAuthTokenKeyringLookup(atMap), sliceBasedAuthTokenBoundaryReader(boundaryAuthTokens))
+		require.NoError(t, err)
+		rs, err := NewRefreshService(ctx, r, hclog.NewNullLogger(), 0, 0)
+		require.NoError(t, err)
+		require.NoError(t, r.AddKeyringToken(ctx, boundaryAddr, KeyringToken{KeyringType: "k", TokenName: "t", AuthTokenId: at.Id}))
+
+		invalid := target("1")
+		invalid.Id = "hst_1"
+		retTargets := []*targets.Target{invalid, target("2")}
+		opts := []Option{
+			WithAliasRetrievalFunc(testStaticResourceRetrievalFunc[*aliases.Alias](t, nil, nil)),
+			WithHostRetrievalFunc(testStaticResourceRetrievalFunc[*hosts.Host](t, nil, nil)),
+			WithStorageBucketRetrievalFunc(testStaticResourceRetrievalFunc[*storagebuckets.StorageBucket](t, nil, nil)),
+			WithSessionRetrievalFunc(testStaticResourceRetrievalFunc[*sessions.Session](t, nil, nil)),
+			WithTargetRetrievalFunc(testStaticResourceRetrievalFunc[*targets.Target](t, [][]*targets.Target{retTargets}, [][]string{nil})),
+		}
+		// Skipping the invalid target doesn't fail the refresh.
+		assert.NoError(t, rs.Refresh(ctx, opts...))
+
+		cachedTargets, err := r.ListTargets(ctx, at.Id)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, retTargets[1:], cachedTargets)
+
+		// The skipped ids are saved to be reported in the cache's status.
+		apiErr, err := r.lookupError(ctx, u, targetResourceType)
+		require.NoError(t, err)
+		require.NotNil(t, apiErr)
+		assert.Contains(t, apiErr.Error, "hst_1")
+	})
+
 	t.Run("set sessions", func(t *testing.T) {
 		s, err := db.Open(ctx)
 		require.NoError(t, err)
@@ -800,6 +857,8 @@ func TestRefresh(t *testing.T) {
 		opts := []Option{
 			WithAliasRetrievalFunc(testStaticResourceRetrievalFunc[*aliases.Alias](t, nil, nil)),
 			WithTargetRetrievalFunc(testStaticResourceRetrievalFunc[*targets.Target](t, nil, nil)),
+			WithHostRetrievalFunc(testStaticResourceRetrievalFunc[*hosts.Host](t, nil, nil)),
+			WithStorageBucketRetrievalFunc(testStaticResourceRetrievalFunc[*storagebuckets.StorageBucket](t, nil, nil)),
 			WithSessionRetrievalFunc(testStaticResourceRetrievalFunc[*sessions.Session](t,
 				[][]*sessions.Session{
 					retSess[:3],
@@ -839,6 +898,8 @@ func TestRefresh(t *testing.T) {
 			alias("4"),
 		}
 		opts := []Option{
+			WithHostRetrievalFunc(testStaticResourceRetrievalFunc[*hosts.Host](t, nil, nil)),
+			WithStorageBucketRetrievalFunc(testStaticResourceRetrievalFunc[*storagebuckets.StorageBucket](t, nil, nil)),
 			WithSessionRetrievalFunc(testStaticResourceRetrievalFunc[*sessions.Session](t, nil, nil)),
 			WithTargetRetrievalFunc(testStaticResourceRetrievalFunc[*targets.Target](t, nil, nil)),
 			WithAliasRetrievalFunc(testStaticResourceRetrievalFunc[*aliases.Alias](t,
@@ -864,6 +925,92 @@ func TestRefresh(t *testing.T) {
 		assert.ElementsMatch(t, retAls[2:], cachedAliases)
 	})
 
+	t.Run("set hosts", func(t *testing.T) {
+		s, err := db.Open(ctx)
+		require.NoError(t, err)
+		r, err := NewRepository(ctx, s, &sync.Map{}, mapBasedAuthTokenKeyringLookup(atMap), sliceBasedAuthTokenBoundaryReader(boundaryAuthTokens))
+		require.NoError(t, err)
+		rs, err := NewRefreshService(ctx, r, hclog.NewNullLogger(), 0, 0)
+		require.NoError(t, err)
+		require.NoError(t, r.AddKeyringToken(ctx, boundaryAddr, KeyringToken{KeyringType: "k", TokenName: "t", AuthTokenId: at.Id}))
+
+		retHosts := []*hosts.Host{
+			host("1"),
+			host("2"),
+			host("3"),
+			host("4"),
+		}
+		opts := []Option{
+			WithAliasRetrievalFunc(testStaticResourceRetrievalFunc[*aliases.Alias](t, nil, nil)),
+			WithSessionRetrievalFunc(testStaticResourceRetrievalFunc[*sessions.Session](t, nil, nil)),
+			WithTargetRetrievalFunc(testStaticResourceRetrievalFunc[*targets.Target](t, nil, nil)),
+			WithStorageBucketRetrievalFunc(testStaticResourceRetrievalFunc[*storagebuckets.StorageBucket](t, nil, nil)),
+			WithHostRetrievalFunc(testStaticResourceRetrievalFunc[*hosts.Host](t,
+				[][]*hosts.Host{
+					retHosts[:3],
+					retHosts[3:],
+				},
+				[][]string{
+					nil,
+					{retHosts[0].Id, retHosts[1].Id},
+				},
+			)),
+		}
+		assert.NoError(t, rs.Refresh(ctx, opts...))
+		cachedHosts, err := r.ListHosts(ctx, at.Id)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, retHosts[:3], cachedHosts)
+
+		// Second call removes the first 2 resources from the cache and adds the last
+		assert.NoError(t, rs.Refresh(ctx, opts...))
+		cachedHosts, err = r.ListHosts(ctx, at.Id)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, retHosts[2:], cachedHosts)
+	})
+
+	t.Run("set storage buckets", func(t *testing.T) {
+		s, err := db.Open(ctx)
+		require.NoError(t, err)
+		r, err := NewRepository(ctx, s, &sync.Map{}, mapBasedAuthTokenKeyringLookup(atMap), sliceBasedAuthTokenBoundaryReader(boundaryAuthTokens))
+		require.NoError(t, err)
+		rs, err := NewRefreshService(ctx, r, hclog.NewNullLogger(), 0, 0)
+		require.NoError(t, err)
+		require.NoError(t, r.AddKeyringToken(ctx, boundaryAddr, KeyringToken{KeyringType: "k", TokenName: "t", AuthTokenId: at.Id}))
+
+		retBuckets := []*storagebuckets.StorageBucket{
+			storageBucket("1"),
+			storageBucket("2"),
+			storageBucket("3"),
+			storageBucket("4"),
+		}
+		opts := []Option{
+			WithAliasRetrievalFunc(testStaticResourceRetrievalFunc[*aliases.Alias](t, nil, nil)),
+			WithSessionRetrievalFunc(testStaticResourceRetrievalFunc[*sessions.Session](t, nil, nil)),
+			WithTargetRetrievalFunc(testStaticResourceRetrievalFunc[*targets.Target](t, nil, nil)),
+			WithHostRetrievalFunc(testStaticResourceRetrievalFunc[*hosts.Host](t, nil, nil)),
+			WithStorageBucketRetrievalFunc(testStaticResourceRetrievalFunc[*storagebuckets.StorageBucket](t,
+				[][]*storagebuckets.StorageBucket{
+					retBuckets[:3],
+					retBuckets[3:],
+				},
+				[][]string{
+					nil,
+					{retBuckets[0].Id, retBuckets[1].Id},
+				},
+			)),
+		}
+		assert.NoError(t, rs.Refresh(ctx, opts...))
+		cachedBuckets, err := r.ListStorageBuckets(ctx, at.Id)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, retBuckets[:3], cachedBuckets)
+
+		// Second call removes the first 2 resources from the cache and adds the last
+		assert.NoError(t, rs.Refresh(ctx, opts...))
+		cachedBuckets, err = r.ListStorageBuckets(ctx, at.Id)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, retBuckets[2:], cachedBuckets)
+	})
+
 	t.Run("error propagates up", func(t *testing.T) {
 		s, err := db.Open(ctx)
 		require.NoError(t, err)
@@ -876,6 +1023,8 @@ func TestRefresh(t *testing.T) {
 		innerErr := errors.New("test error")
 		err = rs.Refresh(ctx,
 			WithAliasRetrievalFunc(testStaticResourceRetrievalFunc[*aliases.Alias](t, nil, nil)),
+			WithHostRetrievalFunc(testStaticResourceRetrievalFunc[*hosts.Host](t, nil, nil)),
+			WithStorageBucketRetrievalFunc(testStaticResourceRetrievalFunc[*storagebuckets.StorageBucket](t, nil, nil)),
 			WithSessionRetrievalFunc(testStaticResourceRetrievalFunc[*sessions.Session](t, nil, nil)),
 			WithTargetRetrievalFunc(func(ctx context.Context, addr, token string, refreshTok RefreshTokenValue) ([]*targets.Target, []string, RefreshTokenValue, error) {
 				require.Equal(t, boundaryAddr, addr)
@@ -886,6 +1035,8 @@ func TestRefresh(t *testing.T) {
 		err = rs.Refresh(ctx,
 			WithAliasRetrievalFunc(testStaticResourceRetrievalFunc[*aliases.Alias](t, nil, nil)),
 			WithTargetRetrievalFunc(testStaticResourceRetrievalFunc[*targets.Target](t, nil, nil)),
+			WithHostRetrievalFunc(testStaticResourceRetrievalFunc[*hosts.Host](t, nil, nil)),
+			WithStorageBucketRetrievalFunc(testStaticResourceRetrievalFunc[*storagebuckets.StorageBucket](t, nil, nil)),
 			WithSessionRetrievalFunc(func(ctx context.Context, addr, token string, refreshTok RefreshTokenValue) ([]*sessions.Session, []string, RefreshTokenValue, error) {
 				require.Equal(t, boundaryAddr, addr)
 				require.Equal(t, at.Token, token)
@@ -918,6 +1069,8 @@ func TestRefresh(t *testing.T) {
 
 		require.NoError(t, rs.Refresh(ctx,
 			WithAliasRetrievalFunc(testStaticResourceRetrievalFunc[*aliases.Alias](t, nil, nil)),
+			WithHostRetrievalFunc(testStaticResourceRetrievalFunc[*hosts.Host](t, nil, nil)),
+			WithStorageBucketRetrievalFunc(testStaticResourceRetrievalFunc[*storagebuckets.StorageBucket](t, nil, nil)),
 			WithSessionRetrievalFunc(testStaticResourceRetrievalFunc[*sessions.Session](t, nil, nil)),
 			WithTargetRetrievalFunc(testStaticResourceRetrievalFunc[*targets.Target](t, nil, nil))))
 
@@ -962,6 +1115,8 @@ func TestRecheckCachingSupport(t *testing.T) {
 		// only get updated with a call to Refresh.
 		assert.NoError(t, rs.RecheckCachingSupport(ctx,
 			WithAliasRetrievalFunc(testNoRefreshRetrievalFunc[*aliases.Alias](t)),
+			WithHostRetrievalFunc(testStaticResourceRetrievalFunc[*hosts.Host](t, nil, nil)),
+			WithStorageBucketRetrievalFunc(testStaticResourceRetrievalFunc[*storagebuckets.StorageBucket](t, nil, nil)),
 			WithSessionRetrievalFunc(testNoRefreshRetrievalFunc[*sessions.Session](t)),
 			WithTargetRetrievalFunc(testNoRefreshRetrievalFunc[*targets.Target](t))))
 
@@ -971,6 +1126,8 @@ func TestRecheckCachingSupport(t *testing.T) {
 
 		err = rs.Refresh(ctx,
 			WithAliasRetrievalFunc(testNoRefreshRetrievalFunc[*aliases.Alias](t)),
+			WithHostRetrievalFunc(testStaticResourceRetrievalFunc[*hosts.Host](t, nil, nil)),
+			WithStorageBucketRetrievalFunc(testStaticResourceRetrievalFunc[*storagebuckets.StorageBucket](t, nil, nil)),
 			WithSessionRetrievalFunc(testNoRefreshRetrievalFunc[*sessions.Session](t)),
 			WithTargetRetrievalFunc(testNoRefreshRetrievalFunc[*targets.Target](t)))
 		assert.ErrorIs(t, err, ErrRefreshNotSupported)
@@ -982,6 +1139,8 @@ func TestRecheckCachingSupport(t *testing.T) {
 		// now a full fetch will work since the user has resources and no refresh token
 		assert.NoError(t, rs.RecheckCachingSupport(ctx,
 			WithAliasRetrievalFunc(testNoRefreshRetrievalFunc[*aliases.Alias](t)),
+			WithHostRetrievalFunc(testStaticResourceRetrievalFunc[*hosts.Host](t, nil, nil)),
+			WithStorageBucketRetrievalFunc(testStaticResourceRetrievalFunc[*storagebuckets.StorageBucket](t, nil, nil)),
 			WithSessionRetrievalFunc(testNoRefreshRetrievalFunc[*sessions.Session](t)),
 			WithTargetRetrievalFunc(testNoRefreshRetrievalFunc[*targets.Target](t))))
 	})
@@ -998,6 +1157,8 @@ func TestRecheckCachingSupport(t *testing.T) {
 		assert.NoError(t, rs.RecheckCachingSupport(ctx,
 			WithAliasRetrievalFunc(testNoRefreshRetrievalFunc[*aliases.Alias](t)),
 			WithTargetRetrievalFunc(testNoRefreshRetrievalFunc[*targets.Target](t)),
+			WithHostRetrievalFunc(testStaticResourceRetrievalFunc[*hosts.Host](t, nil, nil)),
+			WithStorageBucketRetrievalFunc(testStaticResourceRetrievalFunc[*storagebuckets.StorageBucket](t, nil, nil)),
 			WithSessionRetrievalFunc(testNoRefreshRetrievalFunc[*sessions.Session](t))))
 
 		got, err := r.ListSessions(ctx, at.Id)
@@ -1007,6 +1168,8 @@ func TestRecheckCachingSupport(t *testing.T) {
 		err = rs.Refresh(ctx,
 			WithAliasRetrievalFunc(testNoRefreshRetrievalFunc[*aliases.Alias](t)),
 			WithTargetRetrievalFunc(testNoRefreshRetrievalFunc[*targets.Target](t)),
+			WithHostRetrievalFunc(testStaticResourceRetrievalFunc[*hosts.Host](t, nil, nil)),
+			WithStorageBucketRetrievalFunc(testStaticResourceRetrievalFunc[*storagebuckets.StorageBucket](t, nil, nil)),
 			WithSessionRetrievalFunc(testNoRefreshRetrievalFunc[*sessions.Session](t)))
 		assert.ErrorIs(t, err, ErrRefreshNotSupported)
 
@@ -1017,6 +1180,8 @@ func TestRecheckCachingSupport(t *testing.T) {
 		assert.NoError(t, rs.RecheckCachingSupport(ctx,
 			WithAliasRetrievalFunc(testNoRefreshRetrievalFunc[*aliases.Alias](t)),
 			WithTargetRetrievalFunc(testNoRefreshRetrievalFunc[*targets.Target](t)),
+			WithHostRetrievalFunc(testStaticResourceRetrievalFunc[*hosts.Host](t, nil, nil)),
+			WithStorageBucketRetrievalFunc(testStaticResourceRetrievalFunc[*storagebuckets.StorageBucket](t, nil, nil)),
 			WithSessionRetrievalFunc(testNoRefreshRetrievalFunc[*sessions.Session](t))))
 		got, err = r.ListSessions(ctx, at.Id)
 		assert.NoError(t, err)
@@ -1035,6 +1200,8 @@ func TestRecheckCachingSupport(t *testing.T) {
 		assert.NoError(t, rs.RecheckCachingSupport(ctx,
 			WithAliasRetrievalFunc(testNoRefreshRetrievalFunc[*aliases.Alias](t)),
 			WithTargetRetrievalFunc(testNoRefreshRetrievalFunc[*targets.Target](t)),
+			WithHostRetrievalFunc(testStaticResourceRetrievalFunc[*hosts.Host](t, nil, nil)),
+			WithStorageBucketRetrievalFunc(testStaticResourceRetrievalFunc[*storagebuckets.StorageBucket](t, nil, nil)),
 			WithSessionRetrievalFunc(testNoRefreshRetrievalFunc[*sessions.Session](t))))
 
 		got, err := r.ListAliases(ctx, at.Id)
@@ -1044,6 +1211,8 @@ func TestRecheckCachingSupport(t *testing.T) {
 		err = rs.Refresh(ctx,
 			WithAliasRetrievalFunc(testNoRefreshRetrievalFunc[*aliases.Alias](t)),
 			WithTargetRetrievalFunc(testNoRefreshRetrievalFunc[*targets.Target](t)),
+			WithHostRetrievalFunc(testStaticResourceRetrievalFunc[*hosts.Host](t, nil, nil)),
+			WithStorageBucketRetrievalFunc(testStaticResourceRetrievalFunc[*storagebuckets.StorageBucket](t, nil, nil)),
 			WithSessionRetrievalFunc(testNoRefreshRetrievalFunc[*sessions.Session](t)))
 		assert.ErrorIs(t, err, ErrRefreshNotSupported)
 
@@ -1054,6 +1223,8 @@ func TestRecheckCachingSupport(t *testing.T) {
 		assert.NoError(t, rs.RecheckCachingSupport(ctx,
 			WithAliasRetrievalFunc(testNoRefreshRetrievalFunc[*aliases.Alias](t)),
 			WithTargetRetrievalFunc(testNoRefreshRetrievalFunc[*targets.Target](t)),
+			WithHostRetrievalFunc(testStaticResourceRetrievalFunc[*hosts.Host](t, nil, nil)),
+			WithStorageBucketRetrievalFunc(testStaticResourceRetrievalFunc[*storagebuckets.StorageBucket](t, nil, nil)),
 			WithSessionRetrievalFunc(testNoRefreshRetrievalFunc[*sessions.Session](t))))
 		got, err = r.ListAliases(ctx, at.Id)
 		assert.NoError(t, err)
@@ -1072,12 +1243,16 @@ func TestRecheckCachingSupport(t *testing.T) {
 		err = rs.Refresh(ctx,
 			WithAliasRetrievalFunc(testNoRefreshRetrievalFunc[*aliases.Alias](t)),
 			WithTargetRetrievalFunc(testNoRefreshRetrievalFunc[*targets.Target](t)),
+			WithHostRetrievalFunc(testStaticResourceRetrievalFunc[*hosts.Host](t, nil, nil)),
+			WithStorageBucketRetrievalFunc(testStaticResourceRetrievalFunc[*storagebuckets.StorageBucket](t, nil, nil)),
 			WithSessionRetrievalFunc(testNoRefreshRetrievalFunc[*sessions.Session](t)))
 		assert.ErrorIs(t, err, ErrRefreshNotSupported)
 
 		innerErr := errors.New("test error")
 		err = rs.RecheckCachingSupport(ctx,
 			WithAliasRetrievalFunc(testNoRefreshRetrievalFunc[*aliases.Alias](t)),
+			WithHostRetrievalFunc(testStaticResourceRetrievalFunc[*hosts.Host](t, nil, nil)),
+			WithStorageBucketRetrievalFunc(testStaticResourceRetrievalFunc[*storagebuckets.StorageBucket](t, nil, nil)),
 			WithSessionRetrievalFunc(testNoRefreshRetrievalFunc[*sessions.Session](t)),
 			WithTargetRetrievalFunc(func(ctx context.Context, addr, token string, refreshTok RefreshTokenValue) ([]*targets.Target, []string, RefreshTokenValue, error) {
 				require.Equal(t, boundaryAddr, addr)
@@ -1088,6 +1263,8 @@ func TestRecheckCachingSupport(t *testing.T) {
 
 		err = rs.RecheckCachingSupport(ctx,
 			WithAliasRetrievalFunc(testNoRefreshRetrievalFunc[*aliases.Alias](t)),
+			WithHostRetrievalFunc(testStaticResourceRetrievalFunc[*hosts.Host](t, nil, nil)),
+			WithStorageBucketRetrievalFunc(testStaticResourceRetrievalFunc[*storagebuckets.StorageBucket](t, nil, nil)),
 			WithSessionRetrievalFunc(testNoRefreshRetrievalFunc[*sessions.Session](t)),
 			WithTargetRetrievalFunc(func(ctx context.Context, addr, token string, refreshTok RefreshTokenValue) ([]*targets.Target, []string, RefreshTokenValue, error) {
 				require.Equal(t, boundaryAddr, addr)
@@ -1109,6 +1286,8 @@ func TestRecheckCachingSupport(t *testing.T) {
 		err = rs.Refresh(ctx,
 			WithAliasRetrievalFunc(testNoRefreshRetrievalFunc[*aliases.Alias](t)),
 			WithTargetRetrievalFunc(testNoRefreshRetrievalFunc[*targets.Target](t)),
+			WithHostRetrievalFunc(testStaticResourceRetrievalFunc[*hosts.Host](t, nil, nil)),
+			WithStorageBucketRetrievalFunc(testStaticResourceRetrievalFunc[*storagebuckets.StorageBucket](t, nil, nil)),
 			WithSessionRetrievalFunc(testNoRefreshRetrievalFunc[*sessions.Session](t)))
 		assert.ErrorIs(t, err, ErrRefreshNotSupported)
 
@@ -1127,6 +1306,8 @@ func TestRecheckCachingSupport(t *testing.T) {
 
 		err = rs.RecheckCachingSupport(ctx,
 			WithAliasRetrievalFunc(testNoRefreshRetrievalFunc[*aliases.Alias](t)),
+			WithHostRetrievalFunc(testStaticResourceRetrievalFunc[*hosts.Host](t, nil, nil)),
+			WithStorageBucketRetrievalFunc(testStaticResourceRetrievalFunc[*storagebuckets.StorageBucket](t, nil, nil)),
 			WithSessionRetrievalFunc(testNoRefreshRetrievalFunc[*sessions.Session](t)),
 			WithTargetRetrievalFunc(testNoRefreshRetrievalFunc[*targets.Target](t)))
 		assert.NoError(t, err)
@@ -1144,7 +1325,7 @@ func TestRecheckCachingSupport(t *testing.T) {
 
 func target(suffix string) *targets.Target {
 	return &targets.Target{
-		Id:                fmt.Sprintf("target_%s", suffix),
+		Id:                fmt.Sprintf("ttcp_%s", suffix),
 		Name:              fmt.Sprintf("name_%s", suffix),
 		Description:       fmt.Sprintf("description_%s", suffix),
 		Address:           fmt.Sprintf("address_%s", suffix),
@@ -1161,6 +1342,16 @@ func session(suffix string) *sessions.Session {
 	}
 }
 
+func host(suffix string) *hosts.Host {
+	return &hosts.Host{
+		Id:            fmt.Sprintf("hst_%s", suffix),
+		Name:          fmt.Sprintf("name_%s", suffix),
+		Description:   fmt.Sprintf("description_%s", suffix),
+		HostCatalogId: fmt.Sprintf("hcst_%s", suffix),
+		Type:          "static",
+	}
+}
+
 func alias(suffix string) *aliases.Alias {
 	return &aliases.Alias{
 		Id:    fmt.Sprintf("alt_%s", suffix),
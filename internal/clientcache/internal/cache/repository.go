@@ -5,7 +5,9 @@ package cache
 
 import (
 	"context"
+	stderrors "errors"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/hashicorp/boundary/api/authtokens"
@@ -32,10 +34,35 @@ type Repository struct {
 	tokenReadFromBoundaryFn BoundaryTokenReaderFn
 	// idToKeyringlessAuthToken maps an auth token id to an *authtokens.AuthToken
 	idToKeyringlessAuthToken *sync.Map
+	// clock returns the current time
+	clock func() time.Time
+	// emitter is notified of refreshes and of stale resources being listed
+	emitter EventEmitter
+	// metrics is notified of the queries made against the cached resources
+	metrics Metrics
+	// staleThreshold is the age after which listed resources are considered
+	// stale. Zero means listed resources are never considered stale.
+	staleThreshold time.Duration
+	// queryTimeout bounds the database queries made with a context which has
+	// no deadline. Zero means those queries are not bounded.
+	queryTimeout time.Duration
+	// userLocks are the locks used to serialize the writes to the cached
+	// resources of a user, keyed by user id. A lock is only kept while it is
+	// held or waited on so they don't grow with the number of users ever seen.
+	userLocksMu sync.Mutex
+	userLocks   map[string]*userLock
+	// closed is set once MarkClosed has been called
+	closed atomic.Bool
 }
 
 // NewRepository returns a cache repository.  The provided context is stored as
 // the server context for purposes like storing boundary request errors.
+// Supported options are:
+//   - withClock
+//   - WithEventEmitter
+//   - WithStaleThreshold
+//   - WithQueryTimeout
+//   - WithMetrics
 func NewRepository(ctx context.Context, conn *db.DB, idToAuthToken *sync.Map, keyringFn KeyringTokenLookupFn, atReadFn BoundaryTokenReaderFn, opt ...Option) (*Repository, error) {
 	const op = "cache.NewRepository"
 	switch {
@@ -48,6 +75,25 @@ func NewRepository(ctx context.Context, conn *db.DB, idToAuthToken *sync.Map, ke
 	case util.IsNil(atReadFn):
 		return nil, errors.New(ctx, errors.InvalidParameter, op, "missing auth token read function")
 	}
+	opts, err := getOpts(opt...)
+	if err != nil {
+		return nil, errors.Wrap(ctx, err, op)
+	}
+	switch {
+	case opts.withStaleThreshold < 0:
+		return nil, errors.New(ctx, errors.InvalidParameter, op, "stale threshold is negative")
+	case opts.withQueryTimeout < 0:
+		return nil, errors.New(ctx, errors.InvalidParameter, op, "query timeout is negative")
+	}
+	if opts.withClock == nil {
+		opts.withClock = time.Now
+	}
+	if util.IsNil(opts.withEventEmitter) {
+		opts.withEventEmitter = noopEventEmitter{}
+	}
+	if util.IsNil(opts.withMetrics) {
+		opts.withMetrics = noopMetrics{}
+	}
 	return &Repository{
 		serverCtx:               ctx,
 		rw:                      db.New(conn),
@@ -56,11 +102,131 @@ func NewRepository(ctx context.Context, conn *db.DB, idToAuthToken *sync.Map, ke
 		// This is passed in instead of being fully owned by the repo so multiple
 		// instances of the repo can operate on the same backing data
 		idToKeyringlessAuthToken: idToAuthToken,
+		clock:                    opts.withClock,
+		emitter:                  opts.withEventEmitter,
+		metrics:                  opts.withMetrics,
+		staleThreshold:           opts.withStaleThreshold,
+		queryTimeout:             opts.withQueryTimeout,
+		userLocks:                make(map[string]*userLock),
 	}, nil
 }
 
+// MarkClosed marks the repository as closed. Operations on the repository
+// after it is marked closed return an error with the errors.Closed code.
+// Marking an already closed repository is a no-op.
+//
+// The repository doesn't own the store it was created with, since many
+// repositories can share one store, so the store is left open. Whoever opened
+// the store owns it and must release it with db.Close once the repositories
+// using it are no longer needed.
+func (r *Repository) MarkClosed() {
+	r.closed.Store(true)
+}
+
+// errPingRollback is returned by Ping's transaction so its write is rolled
+// back.
+var errPingRollback = stderrors.New("rollback ping")
+
+// Ping confirms the repository's store is open and writable by making a write
+// in a transaction that is then rolled back, so that a read-only or corrupt
+// store returns an error instead of only being detected on the next refresh.
+// It is cheap enough to be called frequently, e.g. for readiness checks.
+func (r *Repository) Ping(ctx context.Context) error {
+	const op = "cache.(Repository).Ping"
+	if err := r.checkOpen(ctx, op); err != nil {
+		return err
+	}
+	_, err := r.rw.DoTx(ctx, 0, db.ExpBackoff{}, func(_ db.Reader, w db.Writer) error {
+		if _, err := w.Exec(ctx, "update schema_version set version = version", nil); err != nil {
+			return err
+		}
+		return errPingRollback
+	})
+	if err != nil && !errors.Is(err, errPingRollback) {
+		return errors.Wrap(ctx, err, op, errors.WithMsg("store is not writable"))
+	}
+	return nil
+}
+
+// checkOpen returns an error if the repository has been closed.
+func (r *Repository) checkOpen(ctx context.Context, op errors.Op) error {
+	if r.closed.Load() {
+		return errors.New(ctx, errors.Closed, op, "store is closed", errors.WithoutEvent())
+	}
+	return nil
+}
+
+// withQueryTimeout returns a context bounded by the repository's query timeout
+// if the provided context doesn't already have a deadline.
+func (r *Repository) withQueryTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || r.queryTimeout == 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, r.queryTimeout)
+}
+
+// Vacuum compacts the cache's database, reclaiming the space left behind by
+// resources which were removed from the cache. Since the cache's database only
+// uses a single connection, it is safe to call while the cache is being read.
+// Supported options are:
+//   - WithRemoveOrphanedUsers
+func (r *Repository) Vacuum(ctx context.Context, opt ...Option) error {
+	const op = "cache.(Repository).Vacuum"
+	if err := r.checkOpen(ctx, op); err != nil {
+		return err
+	}
+	opts, err := getOpts(opt...)
+	if err != nil {
+		return errors.Wrap(ctx, err, op)
+	}
+	if opts.withRemoveOrphanedUsers {
+		if err := r.cleanExpiredOrOrphanedAuthTokens(ctx); err != nil {
+			return errors.Wrap(ctx, err, op)
+		}
+	}
+	if _, err := r.rw.Exec(ctx, "vacuum", nil); err != nil {
+		return errors.Wrap(ctx, err, op)
+	}
+	return nil
+}
+
+// userLock serializes the writes to the cached resources of a single user.
+type userLock struct {
+	mu sync.Mutex
+	// refs is the number of callers holding or waiting on mu
+	refs int
+}
+
+// lockUser blocks until it holds the lock used to serialize the writes to the
+// cached resources of the user with the provided id and returns the function
+// which releases it. Different users never share a lock.
+func (r *Repository) lockUser(id string) (unlock func()) {
+	r.userLocksMu.Lock()
+	l, ok := r.userLocks[id]
+	if !ok {
+		l = &userLock{}
+		r.userLocks[id] = l
+	}
+	l.refs++
+	r.userLocksMu.Unlock()
+
+	l.mu.Lock()
+	return func() {
+		l.mu.Unlock()
+		r.userLocksMu.Lock()
+		defer r.userLocksMu.Unlock()
+		l.refs--
+		if l.refs == 0 {
+			delete(r.userLocks, id)
+		}
+	}
+}
+
 func (r *Repository) saveError(ctx context.Context, u *user, resourceType resourceType, err error) error {
 	const op = "cache.(Repository).saveError"
+	if err := r.checkOpen(ctx, op); err != nil {
+		return err
+	}
 	switch {
 	case !resourceType.valid():
 		return errors.New(ctx, errors.InvalidParameter, op, "resource type is invalid")
@@ -12,6 +12,7 @@ import (
 
 	"github.com/hashicorp/boundary/api"
 	"github.com/hashicorp/boundary/api/aliases"
+	"github.com/hashicorp/boundary/api/targets"
 	"github.com/hashicorp/boundary/internal/db"
 	"github.com/hashicorp/boundary/internal/errors"
 	"github.com/hashicorp/boundary/internal/event"
@@ -51,6 +52,9 @@ func defaultAliasFunc(ctx context.Context, addr, authTok string, refreshTok Refr
 // storage to retrieve and apply only the delta.
 func (r *Repository) refreshAliases(ctx context.Context, u *user, tokens map[AuthToken]string, opt ...Option) error {
 	const op = "cache.(Repository).refreshAliases"
+	if err := r.checkOpen(ctx, op); err != nil {
+		return err
+	}
 	switch {
 	case util.IsNil(u):
 		return errors.New(ctx, errors.InvalidParameter, op, "user is nil")
@@ -146,6 +150,9 @@ func (r *Repository) refreshAliases(ctx context.Context, u *user, tokens map[Aut
 		default:
 			// controller supports caching, but doesn't have any resources
 		}
+		if err := upsertRefreshTime(ctx, w, u, resourceType, r.clock()); err != nil {
+			return err
+		}
 		return nil
 	})
 	if err != nil {
@@ -163,6 +170,9 @@ func (r *Repository) refreshAliases(ctx context.Context, u *user, tokens map[Aut
 // token it will save that as well.
 func (r *Repository) checkCachingAliases(ctx context.Context, u *user, tokens map[AuthToken]string, opt ...Option) error {
 	const op = "cache.(Repository).checkAliasesForSearchability"
+	if err := r.checkOpen(ctx, op); err != nil {
+		return err
+	}
 	switch {
 	case util.IsNil(u):
 		return errors.New(ctx, errors.InvalidParameter, op, "user is nil")
@@ -287,6 +297,9 @@ func upsertAliases(ctx context.Context, w db.Writer, u *user, in []*aliases.Alia
 
 func (r *Repository) ListAliases(ctx context.Context, authTokenId string) ([]*aliases.Alias, error) {
 	const op = "cache.(Repository).ListAliases"
+	if err := r.checkOpen(ctx, op); err != nil {
+		return nil, err
+	}
 	switch {
 	case authTokenId == "":
 		return nil, errors.New(ctx, errors.InvalidParameter, op, "auth token id is missing")
@@ -300,6 +313,9 @@ func (r *Repository) ListAliases(ctx context.Context, authTokenId string) ([]*al
 
 func (r *Repository) QueryAliases(ctx context.Context, authTokenId, query string) ([]*aliases.Alias, error) {
 	const op = "cache.(Repository).QueryAliases"
+	if err := r.checkOpen(ctx, op); err != nil {
+		return nil, err
+	}
 	switch {
 	case authTokenId == "":
 		return nil, errors.New(ctx, errors.InvalidParameter, op, "auth token id is missing")
@@ -318,6 +334,35 @@ func (r *Repository) QueryAliases(ctx context.Context, authTokenId, query string
 	return ret, nil
 }
 
+// ResolveAlias returns the cached target which is the destination of the
+// cached alias with the provided value, for the user associated with the
+// provided auth token id. A NotFound error is returned if the alias, or the
+// target it points to, isn't cached for that user.
+func (r *Repository) ResolveAlias(ctx context.Context, authTokenId, alias string) (*targets.Target, error) {
+	const op = "cache.(Repository).ResolveAlias"
+	if err := r.checkOpen(ctx, op); err != nil {
+		return nil, err
+	}
+	switch {
+	case authTokenId == "":
+		return nil, errors.New(ctx, errors.InvalidParameter, op, "auth token id is missing")
+	case alias == "":
+		return nil, errors.New(ctx, errors.InvalidParameter, op, "alias is missing")
+	}
+	als, err := r.searchAliases(ctx, "value = ?", []any{alias}, withAuthTokenId(authTokenId))
+	if err != nil {
+		return nil, errors.Wrap(ctx, err, op)
+	}
+	if len(als) == 0 || als[0].DestinationId == "" {
+		return nil, errors.New(ctx, errors.NotFound, op, fmt.Sprintf("alias %q not found", alias), errors.WithoutEvent())
+	}
+	t, err := r.GetTarget(ctx, authTokenId, als[0].DestinationId)
+	if err != nil {
+		return nil, errors.Wrap(ctx, err, op)
+	}
+	return t, nil
+}
+
 func (r *Repository) searchAliases(ctx context.Context, condition string, searchArgs []any, opt ...Option) ([]*aliases.Alias, error) {
 	const op = "cache.(Repository).searchAliases"
 	switch {
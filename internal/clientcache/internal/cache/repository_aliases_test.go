@@ -11,10 +11,12 @@ import (
 
 	"github.com/hashicorp/boundary/api/aliases"
 	"github.com/hashicorp/boundary/api/authtokens"
+	"github.com/hashicorp/boundary/api/targets"
 	"github.com/hashicorp/boundary/globals"
 	cachedb "github.com/hashicorp/boundary/internal/clientcache/internal/db"
 	"github.com/hashicorp/boundary/internal/daemon/controller"
 	"github.com/hashicorp/boundary/internal/db"
+	"github.com/hashicorp/boundary/internal/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/exp/maps"
@@ -452,6 +454,104 @@ func TestRepository_QueryAliases(t *testing.T) {
 	})
 }
 
+func TestRepository_ResolveAlias(t *testing.T) {
+	ctx := context.Background()
+	s, err := cachedb.Open(ctx)
+	require.NoError(t, err)
+
+	addr := "address"
+	u1 := &user{
+		Id:      "u1",
+		Address: addr,
+	}
+	at1 := &authtokens.AuthToken{
+		Id:     "at_1",
+		Token:  "at_1_token",
+		UserId: u1.Id,
+	}
+	kt1 := KeyringToken{
+		KeyringType: "k1",
+		TokenName:   "t1",
+		AuthTokenId: at1.Id,
+	}
+	u2 := &user{
+		Id:      "u2",
+		Address: addr,
+	}
+	at2 := &authtokens.AuthToken{
+		Id:     "at_2",
+		Token:  "at_2_token",
+		UserId: u2.Id,
+	}
+	kt2 := KeyringToken{
+		KeyringType: "k2",
+		TokenName:   "t2",
+		AuthTokenId: at2.Id,
+	}
+	atMap := map[ringToken]*authtokens.AuthToken{
+		{"k1", "t1"}: at1,
+		{"k2", "t2"}: at2,
+	}
+	r, err := NewRepository(ctx, s, &sync.Map{}, mapBasedAuthTokenKeyringLookup(atMap), sliceBasedAuthTokenBoundaryReader(maps.Values(atMap)))
+	require.NoError(t, err)
+	require.NoError(t, r.AddKeyringToken(ctx, addr, kt1))
+	require.NoError(t, r.AddKeyringToken(ctx, addr, kt2))
+
+	t1, t2 := target("1"), target("2")
+	_, err = r.refreshTargets(ctx, u1, map[AuthToken]string{{Id: "id"}: "something"},
+		WithTargetRetrievalFunc(testStaticResourceRetrievalFunc(t, [][]*targets.Target{{t1, t2}}, [][]string{nil})))
+	require.NoError(t, err)
+	require.NoError(t, r.refreshAliases(ctx, u1, map[AuthToken]string{{Id: "id"}: "something"},
+		WithAliasRetrievalFunc(testStaticResourceRetrievalFunc(t, [][]*aliases.Alias{{
+			{Id: "alt_1", ScopeId: "global", DestinationId: t1.Id, Value: "one.example", Type: "target"},
+			{Id: "alt_2", ScopeId: "global", DestinationId: t2.Id, Value: "two.example", Type: "target"},
+			{Id: "alt_3", ScopeId: "global", DestinationId: "ttcp_uncached", Value: "uncached.example", Type: "target"},
+		}}, [][]string{nil}))))
+	require.NoError(t, r.refreshAliases(ctx, u2, map[AuthToken]string{{Id: "id"}: "something"},
+		WithAliasRetrievalFunc(testStaticResourceRetrievalFunc(t, [][]*aliases.Alias{{
+			{Id: "alt_4", ScopeId: "global", DestinationId: t1.Id, Value: "other.example", Type: "target"},
+		}}, [][]string{nil}))))
+
+	t.Run("auth token id is missing", func(t *testing.T) {
+		got, err := r.ResolveAlias(ctx, "", "one.example")
+		assert.Nil(t, got)
+		assert.ErrorContains(t, err, "auth token id is missing")
+	})
+	t.Run("alias is missing", func(t *testing.T) {
+		got, err := r.ResolveAlias(ctx, at1.Id, "")
+		assert.Nil(t, got)
+		assert.ErrorContains(t, err, "alias is missing")
+	})
+	t.Run("resolves to destination", func(t *testing.T) {
+		got, err := r.ResolveAlias(ctx, at1.Id, "one.example")
+		require.NoError(t, err)
+		assert.Equal(t, t1.Id, got.Id)
+
+		got, err = r.ResolveAlias(ctx, at1.Id, "two.example")
+		require.NoError(t, err)
+		assert.Equal(t, t2.Id, got.Id)
+	})
+	t.Run("unknown alias", func(t *testing.T) {
+		got, err := r.ResolveAlias(ctx, at1.Id, "unknown.example")
+		assert.Nil(t, got)
+		assert.True(t, errors.Match(errors.T(errors.NotFound), err))
+	})
+	t.Run("uncached destination", func(t *testing.T) {
+		got, err := r.ResolveAlias(ctx, at1.Id, "uncached.example")
+		assert.Nil(t, got)
+		assert.True(t, errors.Match(errors.T(errors.NotFound), err))
+	})
+	t.Run("alias owned by another user", func(t *testing.T) {
+		got, err := r.ResolveAlias(ctx, at1.Id, "other.example")
+		assert.Nil(t, got)
+		assert.True(t, errors.Match(errors.T(errors.NotFound), err))
+
+		got, err = r.ResolveAlias(ctx, at2.Id, "one.example")
+		assert.Nil(t, got)
+		assert.True(t, errors.Match(errors.T(errors.NotFound), err))
+	})
+}
+
 func TestDefaultAliasRetrievalFunc(t *testing.T) {
 	oldDur := globals.RefreshReadLookbackDuration
 	globals.RefreshReadLookbackDuration = 0
@@ -0,0 +1,170 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+
+	"github.com/hashicorp/boundary/api/targets"
+	"github.com/hashicorp/boundary/internal/db"
+	"github.com/hashicorp/boundary/internal/errors"
+	"github.com/hashicorp/boundary/internal/event"
+	"github.com/hashicorp/boundary/internal/types/resource"
+	"github.com/hashicorp/boundary/internal/util"
+)
+
+// exportVersion is the version of the format written by Export. Import only
+// accepts streams written with this version.
+const exportVersion = 1
+
+// cacheExport is the versioned representation of the cache written by Export.
+type cacheExport struct {
+	Version int             `json:"version"`
+	Users   []*exportedUser `json:"users,omitempty"`
+}
+
+type exportedUser struct {
+	Id            string              `json:"id"`
+	Address       string              `json:"address"`
+	KeyringTokens []*exportedKeyToken `json:"keyring_tokens,omitempty"`
+	Targets       []*targets.Target   `json:"targets,omitempty"`
+}
+
+type exportedKeyToken struct {
+	KeyringType string `json:"keyring_type"`
+	TokenName   string `json:"token_name"`
+	AuthTokenId string `json:"auth_token_id"`
+}
+
+// Export writes all the users in the cache, along with their keyring tokens
+// and cached targets, to the provided writer as a versioned JSON document
+// which can be restored using Import. The auth token values themselves are
+// not exported since they are stored in the keyring. Keyringless tokens are
+// only held in memory and so are not exported either.
+func (r *Repository) Export(ctx context.Context, w io.Writer) error {
+	const op = "cache.(Repository).Export"
+	if err := r.checkOpen(ctx, op); err != nil {
+		return err
+	}
+	switch {
+	case util.IsNil(w):
+		return errors.New(ctx, errors.InvalidParameter, op, "writer is nil")
+	}
+	us, err := r.listUsers(ctx)
+	if err != nil {
+		return errors.Wrap(ctx, err, op)
+	}
+	exp := cacheExport{Version: exportVersion}
+	for _, u := range us {
+		eu := &exportedUser{
+			Id:      u.Id,
+			Address: u.Address,
+		}
+		ats, err := r.listTokens(ctx, u)
+		if err != nil {
+			return errors.Wrap(ctx, err, op)
+		}
+		for _, at := range ats {
+			kts, err := r.listKeyringTokens(ctx, at)
+			if err != nil {
+				return errors.Wrap(ctx, err, op)
+			}
+			for _, kt := range kts {
+				eu.KeyringTokens = append(eu.KeyringTokens, &exportedKeyToken{
+					KeyringType: kt.KeyringType,
+					TokenName:   kt.TokenName,
+					AuthTokenId: kt.AuthTokenId,
+				})
+			}
+		}
+		if eu.Targets, err = r.searchTargets(ctx, "true", nil, withUserId(u.Id), WithSort("id", AscendingSortDirection)); err != nil {
+			return errors.Wrap(ctx, err, op)
+		}
+		exp.Users = append(exp.Users, eu)
+	}
+	if err := json.NewEncoder(w).Encode(&exp); err != nil {
+		return errors.Wrap(ctx, err, op)
+	}
+	return nil
+}
+
+// Import restores the users, keyring tokens and cached targets written by
+// Export from the provided reader. Keyring tokens are only restored if they
+// can still be found in the keyring and read from boundary, and a user's
+// targets are only restored if at least one of their keyring tokens was.
+// Targets without a valid target id are skipped, as they are when refreshing.
+// Importing the same data more than once has the same result as importing it
+// once.
+func (r *Repository) Import(ctx context.Context, rd io.Reader) error {
+	const op = "cache.(Repository).Import"
+	if err := r.checkOpen(ctx, op); err != nil {
+		return err
+	}
+	switch {
+	case util.IsNil(rd):
+		return errors.New(ctx, errors.InvalidParameter, op, "reader is nil")
+	}
+	var exp cacheExport
+	if err := json.NewDecoder(rd).Decode(&exp); err != nil {
+		return errors.Wrap(ctx, err, op, errors.WithCode(errors.InvalidParameter))
+	}
+	if exp.Version != exportVersion {
+		return errors.New(ctx, errors.InvalidParameter, op, fmt.Sprintf("unsupported export version %d", exp.Version))
+	}
+
+	var retErr error
+	for _, eu := range exp.Users {
+		if eu == nil {
+			continue
+		}
+		var imported bool
+		for _, ekt := range eu.KeyringTokens {
+			if ekt == nil {
+				continue
+			}
+			kt := KeyringToken{
+				KeyringType: ekt.KeyringType,
+				TokenName:   ekt.TokenName,
+				AuthTokenId: ekt.AuthTokenId,
+			}
+			// The token's targets are imported for the exported user, so the
+			// token must still belong to that user.
+			if at := r.tokenKeyringFn(kt.KeyringType, kt.TokenName); at == nil || at.UserId != eu.Id {
+				event.WriteSysEvent(ctx, op, "skipping keyring token which is no longer in the keyring for the user", "user_id", eu.Id, "auth_token_id", kt.AuthTokenId)
+				continue
+			}
+			if err := r.AddKeyringToken(ctx, eu.Address, kt); err != nil {
+				event.WriteSysEvent(ctx, op, "skipping keyring token which can no longer be validated", "user_id", eu.Id, "auth_token_id", kt.AuthTokenId)
+				continue
+			}
+			imported = true
+		}
+		if !imported {
+			continue
+		}
+		// Like a refresh, targets without a valid id are never cached.
+		ts, skippedIds, err := partitionTargetsById(ctx, eu.Targets, resource.Target.Prefixes())
+		if err != nil {
+			return errors.Wrap(ctx, err, op)
+		}
+		if len(skippedIds) > 0 {
+			event.WriteSysEvent(ctx, op, "skipping targets with invalid ids", "user_id", eu.Id, "target_ids", skippedIds)
+		}
+		u := &user{Id: eu.Id, Address: eu.Address}
+		unlock := r.lockUser(u.Id)
+		_, err = r.rw.DoTx(ctx, db.StdRetryCnt, db.ExpBackoff{}, func(_ db.Reader, w db.Writer) error {
+			_, err := upsertTargets(ctx, w, u, ts, r.clock())
+			return err
+		})
+		unlock()
+		if err != nil {
+			retErr = stderrors.Join(retErr, errors.Wrap(ctx, err, op, errors.WithMsg("for user %q", eu.Id)))
+		}
+	}
+	return retErr
+}
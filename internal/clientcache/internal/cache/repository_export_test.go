@@ -0,0 +1,151 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/boundary/api/authtokens"
+	"github.com/hashicorp/boundary/api/targets"
+	cachedb "github.com/hashicorp/boundary/internal/clientcache/internal/db"
+	"github.com/hashicorp/boundary/internal/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_ExportImport(t *testing.T) {
+	ctx := context.Background()
+
+	addr := "address"
+	u1 := &user{
+		Id:      "u1",
+		Address: addr,
+	}
+	at1 := &authtokens.AuthToken{
+		Id:     "at_1",
+		Token:  "at_1_token",
+		UserId: u1.Id,
+	}
+	kt1 := KeyringToken{KeyringType: "k1", TokenName: "t1", AuthTokenId: at1.Id}
+	u2 := &user{
+		Id:      "u2",
+		Address: addr,
+	}
+	at2 := &authtokens.AuthToken{
+		Id:     "at_2",
+		Token:  "at_2_token",
+		UserId: u2.Id,
+	}
+	kt2 := KeyringToken{KeyringType: "k2", TokenName: "t2", AuthTokenId: at2.Id}
+	atMap := map[ringToken]*authtokens.AuthToken{
+		{"k1", "t1"}: at1,
+		{"k2", "t2"}: at2,
+	}
+
+	s, err := cachedb.Open(ctx)
+	require.NoError(t, err)
+	r, err := NewRepository(ctx, s, &sync.Map{}, mapBasedAuthTokenKeyringLookup(atMap), sliceBasedAuthTokenBoundaryReader([]*authtokens.AuthToken{at1, at2}))
+	require.NoError(t, err)
+	require.NoError(t, r.AddKeyringToken(ctx, addr, kt1))
+	require.NoError(t, r.AddKeyringToken(ctx, addr, kt2))
+
+	u1Targets := []*targets.Target{target("1"), target("2")}
+	u2Targets := []*targets.Target{target("3")}
+	_, err = r.refreshTargets(ctx, u1, map[AuthToken]string{{Id: "id"}: "something"},
+		WithTargetRetrievalFunc(testStaticResourceRetrievalFunc(t, [][]*targets.Target{u1Targets}, [][]string{nil})))
+	require.NoError(t, err)
+	_, err = r.refreshTargets(ctx, u2, map[AuthToken]string{{Id: "id"}: "something"},
+		WithTargetRetrievalFunc(testStaticResourceRetrievalFunc(t, [][]*targets.Target{u2Targets}, [][]string{nil})))
+	require.NoError(t, err)
+
+	var exported bytes.Buffer
+	require.NoError(t, r.Export(ctx, &exported))
+
+	t.Run("round trip", func(t *testing.T) {
+		s, err := cachedb.Open(ctx)
+		require.NoError(t, err)
+		imp, err := NewRepository(ctx, s, &sync.Map{}, mapBasedAuthTokenKeyringLookup(atMap), sliceBasedAuthTokenBoundaryReader([]*authtokens.AuthToken{at1, at2}))
+		require.NoError(t, err)
+
+		// Importing twice is the same as importing once.
+		require.NoError(t, imp.Import(ctx, bytes.NewReader(exported.Bytes())))
+		require.NoError(t, imp.Import(ctx, bytes.NewReader(exported.Bytes())))
+
+		us, err := imp.listUsers(ctx)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []*user{u1, u2}, us)
+		for _, kt := range []KeyringToken{kt1, kt2} {
+			got, err := imp.listKeyringTokens(ctx, &AuthToken{Id: kt.AuthTokenId})
+			require.NoError(t, err)
+			assert.Equal(t, []*KeyringToken{&kt}, got)
+		}
+
+		got, err := imp.ListTargets(ctx, at1.Id)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, u1Targets, got)
+		got, err = imp.ListTargets(ctx, at2.Id)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, u2Targets, got)
+
+		var reexported bytes.Buffer
+		require.NoError(t, imp.Export(ctx, &reexported))
+		assert.JSONEq(t, exported.String(), reexported.String())
+	})
+	t.Run("skips tokens which no longer validate", func(t *testing.T) {
+		s, err := cachedb.Open(ctx)
+		require.NoError(t, err)
+		imp, err := NewRepository(ctx, s, &sync.Map{}, mapBasedAuthTokenKeyringLookup(atMap), sliceBasedAuthTokenBoundaryReader([]*authtokens.AuthToken{at1}))
+		require.NoError(t, err)
+		require.NoError(t, imp.Import(ctx, bytes.NewReader(exported.Bytes())))
+
+		us, err := imp.listUsers(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []*user{u1}, us)
+		got, err := imp.ListTargets(ctx, at1.Id)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, u1Targets, got)
+		got, err = imp.ListTargets(ctx, at2.Id)
+		require.NoError(t, err)
+		assert.Empty(t, got)
+	})
+	t.Run("skips targets with invalid ids", func(t *testing.T) {
+		var exp cacheExport
+		require.NoError(t, json.Unmarshal(exported.Bytes(), &exp))
+		for _, eu := range exp.Users {
+			if eu.Id == u1.Id {
+				eu.Targets = append(eu.Targets, &targets.Target{Id: "hst_1234567890", Name: "not a target"}, nil)
+			}
+		}
+		withInvalid, err := json.Marshal(&exp)
+		require.NoError(t, err)
+
+		s, err := cachedb.Open(ctx)
+		require.NoError(t, err)
+		imp, err := NewRepository(ctx, s, &sync.Map{}, mapBasedAuthTokenKeyringLookup(atMap), sliceBasedAuthTokenBoundaryReader([]*authtokens.AuthToken{at1, at2}))
+		require.NoError(t, err)
+		require.NoError(t, imp.Import(ctx, bytes.NewReader(withInvalid)))
+
+		got, err := imp.ListTargets(ctx, at1.Id)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, u1Targets, got)
+	})
+	t.Run("unsupported version", func(t *testing.T) {
+		err := r.Import(ctx, strings.NewReader(`{"version": 2}`))
+		assert.Truef(t, errors.Match(errors.T(errors.InvalidParameter), err), "unexpected error: %v", err)
+		assert.ErrorContains(t, err, "unsupported export version 2")
+	})
+	t.Run("invalid json", func(t *testing.T) {
+		err := r.Import(ctx, strings.NewReader(`{`))
+		assert.Truef(t, errors.Match(errors.T(errors.InvalidParameter), err), "unexpected error: %v", err)
+	})
+	t.Run("nil writer and reader", func(t *testing.T) {
+		assert.Truef(t, errors.Match(errors.T(errors.InvalidParameter), r.Export(ctx, nil)), "expected invalid parameter")
+		assert.Truef(t, errors.Match(errors.T(errors.InvalidParameter), r.Import(ctx, nil)), "expected invalid parameter")
+	})
+}
@@ -0,0 +1,422 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+
+	"github.com/hashicorp/boundary/api"
+	"github.com/hashicorp/boundary/api/hostcatalogs"
+	"github.com/hashicorp/boundary/api/hosts"
+	"github.com/hashicorp/boundary/internal/db"
+	"github.com/hashicorp/boundary/internal/errors"
+	"github.com/hashicorp/boundary/internal/event"
+	"github.com/hashicorp/boundary/internal/util"
+	"github.com/hashicorp/mql"
+)
+
+// HostRetrievalFunc is a function that retrieves hosts
+// from the provided boundary addr using the provided token.
+type HostRetrievalFunc func(ctx context.Context, addr, authTok string, refreshTok RefreshTokenValue) (ret []*hosts.Host, removedIds []string, refreshToken RefreshTokenValue, err error)
+
+// defaultHostFunc retrieves the hosts in every host catalog the user can list.
+// Hosts can only be listed per host catalog, so the refresh token it uses is
+// the json encoded map of host catalog ids to the list token for that host
+// catalog. If a host catalog from a previous refresh is no longer listed, the
+// ids of the hosts it contained are unknown so api.ErrInvalidListToken is
+// returned to force a full refresh.
+func defaultHostFunc(ctx context.Context, addr, authTok string, refreshTok RefreshTokenValue) ([]*hosts.Host, []string, RefreshTokenValue, error) {
+	const op = "cache.defaultHostFunc"
+	client, err := api.NewClient(&api.Config{
+		Addr:  addr,
+		Token: authTok,
+	})
+	if err != nil {
+		return nil, nil, "", errors.Wrap(ctx, err, op)
+	}
+	oldListTokens := make(map[string]string)
+	if refreshTok != "" {
+		if err := json.Unmarshal([]byte(refreshTok), &oldListTokens); err != nil {
+			return nil, nil, "", api.ErrInvalidListToken
+		}
+	}
+
+	hcl, err := hostcatalogs.NewClient(client).List(ctx, "global", hostcatalogs.WithRecursive(true))
+	if err != nil {
+		return nil, nil, "", errors.Wrap(ctx, err, op)
+	}
+	hClient := hosts.NewClient(client)
+	var ret []*hosts.Host
+	var removedIds []string
+	newListTokens := make(map[string]string, len(hcl.Items))
+	for _, hc := range hcl.Items {
+		l, err := hClient.List(ctx, hc.Id, hosts.WithListToken(oldListTokens[hc.Id]))
+		if err != nil {
+			if api.ErrInvalidListToken.Is(err) {
+				return nil, nil, "", err
+			}
+			return nil, nil, "", errors.Wrap(ctx, err, op)
+		}
+		if l.ResponseType == "" {
+			return nil, nil, "", ErrRefreshNotSupported
+		}
+		ret = append(ret, l.Items...)
+		removedIds = append(removedIds, l.RemovedIds...)
+		newListTokens[hc.Id] = l.ListToken
+		delete(oldListTokens, hc.Id)
+	}
+	if len(oldListTokens) > 0 {
+		// Host catalogs were removed since the last refresh.
+		return nil, nil, "", api.ErrInvalidListToken
+	}
+	newRefreshTok, err := json.Marshal(newListTokens)
+	if err != nil {
+		return nil, nil, "", errors.Wrap(ctx, err, op)
+	}
+	return ret, removedIds, RefreshTokenValue(newRefreshTok), nil
+}
+
+// refreshHosts attempts to refresh the hosts for the provided user
+// using the provided tokens. If available, it uses the refresh tokens in
+// storage to retrieve and apply only the delta.
+func (r *Repository) refreshHosts(ctx context.Context, u *user, tokens map[AuthToken]string, opt ...Option) error {
+	const op = "cache.(Repository).refreshHosts"
+	if err := r.checkOpen(ctx, op); err != nil {
+		return err
+	}
+	switch {
+	case util.IsNil(u):
+		return errors.New(ctx, errors.InvalidParameter, op, "user is nil")
+	case u.Id == "":
+		return errors.New(ctx, errors.InvalidParameter, op, "user id is missing")
+	}
+	const resourceType = hostResourceType
+
+	opts, err := getOpts(opt...)
+	if err != nil {
+		return errors.Wrap(ctx, err, op)
+	}
+	if opts.withHostRetrievalFunc == nil {
+		opts.withHostRetrievalFunc = defaultHostFunc
+	}
+
+	var oldRefreshTokenVal RefreshTokenValue
+	oldRefreshToken, err := r.lookupRefreshToken(ctx, u, resourceType)
+	if err != nil {
+		return errors.Wrap(ctx, err, op)
+	}
+	if oldRefreshToken != nil {
+		oldRefreshTokenVal = oldRefreshToken.RefreshToken
+	}
+
+	// Find and use a token for retrieving hosts
+	var gotResponse bool
+	var resp []*hosts.Host
+	var removedIds []string
+	var newRefreshToken RefreshTokenValue
+	var unsupportedCacheRequest bool
+	var retErr error
+	for at, t := range tokens {
+		resp, removedIds, newRefreshToken, err = opts.withHostRetrievalFunc(ctx, u.Address, t, oldRefreshTokenVal)
+		if api.ErrInvalidListToken.Is(err) {
+			event.WriteSysEvent(ctx, op, "old list token is no longer valid, starting new initial fetch", "user_id", u.Id)
+			if err := r.deleteRefreshToken(ctx, u, resourceType); err != nil {
+				return errors.Wrap(ctx, err, op)
+			}
+			// try again without the refresh token
+			oldRefreshToken = nil
+			resp, removedIds, newRefreshToken, err = opts.withHostRetrievalFunc(ctx, u.Address, t, "")
+		}
+		if err != nil {
+			if err == ErrRefreshNotSupported {
+				unsupportedCacheRequest = true
+			} else {
+				retErr = stderrors.Join(retErr, errors.Wrap(ctx, err, op, errors.WithMsg("for token %q", at.Id)))
+				continue
+			}
+		}
+		gotResponse = true
+		break
+	}
+	if retErr != nil {
+		if saveErr := r.saveError(r.serverCtx, u, resourceType, retErr); saveErr != nil {
+			return stderrors.Join(err, errors.Wrap(ctx, saveErr, op))
+		}
+	}
+	if !gotResponse {
+		return retErr
+	}
+
+	var numDeleted int
+	_, err = r.rw.DoTx(ctx, db.StdRetryCnt, db.ExpBackoff{}, func(_ db.Reader, w db.Writer) error {
+		var err error
+		switch {
+		case oldRefreshToken == nil || unsupportedCacheRequest:
+			if numDeleted, err = w.Exec(ctx, "delete from host where fk_user_id = @fk_user_id",
+				[]any{sql.Named("fk_user_id", u.Id)}); err != nil {
+				return err
+			}
+		case len(removedIds) > 0:
+			if numDeleted, err = w.Exec(ctx, "delete from host where fk_user_id = @fk_user_id and id in @ids",
+				[]any{sql.Named("fk_user_id", u.Id), sql.Named("ids", removedIds)}); err != nil {
+				return err
+			}
+		}
+		switch {
+		case unsupportedCacheRequest:
+			if err := upsertRefreshToken(ctx, w, u, resourceType, sentinelNoRefreshToken); err != nil {
+				return err
+			}
+		case newRefreshToken != "":
+			if err := upsertHosts(ctx, w, u, resp); err != nil {
+				return err
+			}
+			if err := upsertRefreshToken(ctx, w, u, resourceType, newRefreshToken); err != nil {
+				return err
+			}
+		default:
+			// controller supports caching, but doesn't have any resources
+		}
+		if err := upsertRefreshTime(ctx, w, u, resourceType, r.clock()); err != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(ctx, err, op)
+	}
+	if unsupportedCacheRequest {
+		return ErrRefreshNotSupported
+	}
+	event.WriteSysEvent(ctx, op, "hosts updated", "deleted", numDeleted, "upserted", len(resp), "user_id", u.Id)
+	return nil
+}
+
+// checkCachingHosts fetches all hosts for the provided user and sets the
+// cache to match the values returned. If the response includes a refresh
+// token it will save that as well.
+func (r *Repository) checkCachingHosts(ctx context.Context, u *user, tokens map[AuthToken]string, opt ...Option) error {
+	const op = "cache.(Repository).checkCachingHosts"
+	if err := r.checkOpen(ctx, op); err != nil {
+		return err
+	}
+	switch {
+	case util.IsNil(u):
+		return errors.New(ctx, errors.InvalidParameter, op, "user is nil")
+	case u.Id == "":
+		return errors.New(ctx, errors.InvalidParameter, op, "user id is missing")
+	}
+	const resourceType = hostResourceType
+
+	opts, err := getOpts(opt...)
+	if err != nil {
+		return errors.Wrap(ctx, err, op)
+	}
+	if opts.withHostRetrievalFunc == nil {
+		opts.withHostRetrievalFunc = defaultHostFunc
+	}
+
+	// Find and use a token for retrieving hosts
+	var gotResponse bool
+	var resp []*hosts.Host
+	var newRefreshToken RefreshTokenValue
+	var unsupportedCacheRequest bool
+	var retErr error
+	for at, t := range tokens {
+		resp, _, newRefreshToken, err = opts.withHostRetrievalFunc(ctx, u.Address, t, "")
+		if err != nil {
+			if err == ErrRefreshNotSupported {
+				unsupportedCacheRequest = true
+			} else {
+				retErr = stderrors.Join(retErr, errors.Wrap(ctx, err, op, errors.WithMsg("for token %q", at.Id)))
+				continue
+			}
+		}
+		gotResponse = true
+		break
+	}
+	if retErr != nil {
+		if saveErr := r.saveError(r.serverCtx, u, resourceType, retErr); saveErr != nil {
+			return stderrors.Join(err, errors.Wrap(ctx, saveErr, op))
+		}
+	}
+	if !gotResponse {
+		return retErr
+	}
+
+	var numDeleted int
+	_, err = r.rw.DoTx(ctx, db.StdRetryCnt, db.ExpBackoff{}, func(_ db.Reader, w db.Writer) error {
+		switch {
+		case unsupportedCacheRequest:
+			if err := upsertRefreshToken(ctx, w, u, resourceType, sentinelNoRefreshToken); err != nil {
+				return err
+			}
+		case newRefreshToken != "":
+			var err error
+			if numDeleted, err = w.Exec(ctx, "delete from host where fk_user_id = @fk_user_id",
+				[]any{sql.Named("fk_user_id", u.Id)}); err != nil {
+				return err
+			}
+			if err := upsertHosts(ctx, w, u, resp); err != nil {
+				return err
+			}
+			if err := upsertRefreshToken(ctx, w, u, resourceType, newRefreshToken); err != nil {
+				return err
+			}
+		default:
+			// We know the controller supports caching, but doesn't have a
+			// refresh token so clear out any refresh token we have for this resource.
+			if err := deleteRefreshToken(ctx, w, u, resourceType); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(ctx, err, op)
+	}
+	if unsupportedCacheRequest {
+		return ErrRefreshNotSupported
+	}
+	event.WriteSysEvent(ctx, op, "hosts updated", "deleted", numDeleted, "upserted", len(resp), "user_id", u.Id)
+	return nil
+}
+
+// upsertHosts upserts the provided hosts to be stored for the provided user.
+func upsertHosts(ctx context.Context, w db.Writer, u *user, in []*hosts.Host) error {
+	const op = "cache.upsertHosts"
+	switch {
+	case util.IsNil(w):
+		return errors.New(ctx, errors.InvalidParameter, op, "writer is nil")
+	case !w.IsTx(ctx):
+		return errors.New(ctx, errors.InvalidParameter, op, "writer isn't in a transaction")
+	case util.IsNil(u):
+		return errors.New(ctx, errors.InvalidParameter, op, "user is nil")
+	}
+
+	for _, h := range in {
+		item, err := json.Marshal(h)
+		if err != nil {
+			return errors.Wrap(ctx, err, op)
+		}
+		newHost := &Host{
+			FkUserId:      u.Id,
+			Id:            h.Id,
+			HostCatalogId: h.HostCatalogId,
+			Name:          h.Name,
+			Description:   h.Description,
+			Type:          h.Type,
+			ExternalId:    h.ExternalId,
+			ExternalName:  h.ExternalName,
+			Item:          string(item),
+		}
+		onConflict := db.OnConflict{
+			Target: db.Columns{"fk_user_id", "id"},
+			Action: db.SetColumns([]string{"host_catalog_id", "name", "description", "type", "external_id", "external_name", "item"}),
+		}
+		if err := w.Create(ctx, newHost, db.WithOnConflict(&onConflict)); err != nil {
+			return errors.Wrap(ctx, err, op)
+		}
+	}
+	return nil
+}
+
+func (r *Repository) ListHosts(ctx context.Context, authTokenId string) ([]*hosts.Host, error) {
+	const op = "cache.(Repository).ListHosts"
+	if err := r.checkOpen(ctx, op); err != nil {
+		return nil, err
+	}
+	switch {
+	case authTokenId == "":
+		return nil, errors.New(ctx, errors.InvalidParameter, op, "auth token id is missing")
+	}
+	ret, err := r.searchHosts(ctx, "true", nil, withAuthTokenId(authTokenId))
+	if err != nil {
+		return nil, errors.Wrap(ctx, err, op)
+	}
+	return ret, nil
+}
+
+func (r *Repository) QueryHosts(ctx context.Context, authTokenId, query string) ([]*hosts.Host, error) {
+	const op = "cache.(Repository).QueryHosts"
+	if err := r.checkOpen(ctx, op); err != nil {
+		return nil, err
+	}
+	switch {
+	case authTokenId == "":
+		return nil, errors.New(ctx, errors.InvalidParameter, op, "auth token id is missing")
+	case query == "":
+		return nil, errors.New(ctx, errors.InvalidParameter, op, "query is missing")
+	}
+
+	w, err := mql.Parse(query, Host{}, mql.WithIgnoredFields("FkUserId", "Item"))
+	if err != nil {
+		return nil, errors.Wrap(ctx, err, op, errors.WithCode(errors.InvalidParameter))
+	}
+	ret, err := r.searchHosts(ctx, w.Condition, w.Args, withAuthTokenId(authTokenId))
+	if err != nil {
+		return nil, errors.Wrap(ctx, err, op)
+	}
+	return ret, nil
+}
+
+func (r *Repository) searchHosts(ctx context.Context, condition string, searchArgs []any, opt ...Option) ([]*hosts.Host, error) {
+	const op = "cache.(Repository).searchHosts"
+	switch {
+	case condition == "":
+		return nil, errors.New(ctx, errors.InvalidParameter, op, "condition is missing")
+	}
+
+	opts, err := getOpts(opt...)
+	if err != nil {
+		return nil, errors.Wrap(ctx, err, op)
+	}
+	switch {
+	case opts.withAuthTokenId != "" && opts.withUserId != "":
+		return nil, errors.New(ctx, errors.InvalidParameter, op, "both user id and auth token id were provided")
+	case opts.withAuthTokenId == "" && opts.withUserId == "":
+		return nil, errors.New(ctx, errors.InvalidParameter, op, "neither user id nor auth token id were provided")
+	case opts.withAuthTokenId != "":
+		condition = fmt.Sprintf("%s and fk_user_id in (select user_id from auth_token where id = ?)", condition)
+		searchArgs = append(searchArgs, opts.withAuthTokenId)
+	case opts.withUserId != "":
+		condition = fmt.Sprintf("%s and fk_user_id = ?", condition)
+		searchArgs = append(searchArgs, opts.withUserId)
+	}
+
+	var cachedHosts []*Host
+	if err := r.rw.SearchWhere(ctx, &cachedHosts, condition, searchArgs, db.WithLimit(-1)); err != nil {
+		return nil, errors.Wrap(ctx, err, op)
+	}
+
+	retHosts := make([]*hosts.Host, 0, len(cachedHosts))
+	for _, cachedHost := range cachedHosts {
+		var h hosts.Host
+		if err := json.Unmarshal([]byte(cachedHost.Item), &h); err != nil {
+			return nil, errors.Wrap(ctx, err, op)
+		}
+		retHosts = append(retHosts, &h)
+	}
+	return retHosts, nil
+}
+
+type Host struct {
+	FkUserId      string `gorm:"primaryKey"`
+	Id            string `gorm:"primaryKey"`
+	HostCatalogId string `gorm:"default:null"`
+	Name          string `gorm:"default:null"`
+	Description   string `gorm:"default:null"`
+	Type          string `gorm:"default:null"`
+	ExternalId    string `gorm:"default:null"`
+	ExternalName  string `gorm:"default:null"`
+	Item          string `gorm:"default:null"`
+}
+
+func (*Host) TableName() string {
+	return "host"
+}
@@ -0,0 +1,419 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/boundary/api/authtokens"
+	"github.com/hashicorp/boundary/api/hostcatalogs"
+	"github.com/hashicorp/boundary/api/hosts"
+	"github.com/hashicorp/boundary/globals"
+	cachedb "github.com/hashicorp/boundary/internal/clientcache/internal/db"
+	"github.com/hashicorp/boundary/internal/daemon/controller"
+	"github.com/hashicorp/boundary/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/maps"
+)
+
+func TestRepository_refreshHosts(t *testing.T) {
+	ctx := context.Background()
+	s, err := cachedb.Open(ctx)
+	require.NoError(t, err)
+
+	addr := "address"
+	u := user{
+		Id:      "u1",
+		Address: addr,
+	}
+	at := &authtokens.AuthToken{
+		Id:     "at_1",
+		Token:  "at_1_token",
+		UserId: u.Id,
+	}
+	kt := KeyringToken{
+		KeyringType: "keyring",
+		TokenName:   "token",
+		AuthTokenId: at.Id,
+	}
+	atMap := map[ringToken]*authtokens.AuthToken{
+		{kt.KeyringType, kt.TokenName}: at,
+	}
+	r, err := NewRepository(ctx, s, &sync.Map{}, mapBasedAuthTokenKeyringLookup(atMap), sliceBasedAuthTokenBoundaryReader(maps.Values(atMap)))
+	require.NoError(t, err)
+	require.NoError(t, r.AddKeyringToken(ctx, addr, kt))
+
+	hs := []*hosts.Host{
+		host("1"),
+		host("2"),
+		host("3"),
+	}
+	var want []*Host
+	for _, h := range hs {
+		hi, err := json.Marshal(h)
+		require.NoError(t, err)
+		want = append(want, &Host{
+			FkUserId:      u.Id,
+			Id:            h.Id,
+			HostCatalogId: h.HostCatalogId,
+			Name:          h.Name,
+			Description:   h.Description,
+			Type:          h.Type,
+			ExternalId:    h.ExternalId,
+			ExternalName:  h.ExternalName,
+			Item:          string(hi),
+		})
+	}
+	cases := []struct {
+		name          string
+		u             *user
+		hosts         []*hosts.Host
+		want          []*Host
+		errorContains string
+	}{
+		{
+			name: "Success",
+			u: &user{
+				Id:      at.UserId,
+				Address: addr,
+			},
+			hosts: hs,
+			want:  want,
+		},
+		// this test case must run after the above test case so as to exercise
+		// the update logic of refresh.
+		{
+			name: "repeated host with different values",
+			u: &user{
+				Id:      at.UserId,
+				Address: addr,
+			},
+			hosts: append(hs, &hosts.Host{
+				Id:   hs[0].Id,
+				Name: "a different name",
+			}),
+			want: append(want[1:],
+				&Host{
+					FkUserId: want[0].FkUserId,
+					Id:       want[0].Id,
+					Name:     "a different name",
+					Item:     `{"id":"hst_1","name":"a different name","created_time":"0001-01-01T00:00:00Z","updated_time":"0001-01-01T00:00:00Z"}`,
+				}),
+		},
+		{
+			name:          "nil user",
+			u:             nil,
+			hosts:         hs,
+			errorContains: "user is nil",
+		},
+		{
+			name: "missing user Id",
+			u: &user{
+				Address: addr,
+			},
+			hosts:         hs,
+			errorContains: "user id is missing",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := r.refreshHosts(ctx, tc.u, map[AuthToken]string{{Id: "id"}: "something"},
+				WithHostRetrievalFunc(testStaticResourceRetrievalFunc(t, [][]*hosts.Host{tc.hosts}, [][]string{nil})))
+			if tc.errorContains == "" {
+				assert.NoError(t, err)
+				rw := db.New(s)
+				var got []*Host
+				require.NoError(t, rw.SearchWhere(ctx, &got, "true", nil))
+				assert.ElementsMatch(t, got, tc.want)
+
+				t.Cleanup(func() {
+					refTok := &refreshToken{
+						UserId:       tc.u.Id,
+						ResourceType: hostResourceType,
+					}
+					_, err := r.rw.Delete(ctx, refTok)
+					require.NoError(t, err)
+				})
+			} else {
+				assert.ErrorContains(t, err, tc.errorContains)
+			}
+		})
+	}
+}
+
+func TestRepository_RefreshHosts_withRefreshTokens(t *testing.T) {
+	ctx := context.Background()
+	s, err := cachedb.Open(ctx)
+	require.NoError(t, err)
+
+	addr := "address"
+	u := user{
+		Id:      "u1",
+		Address: addr,
+	}
+	at := &authtokens.AuthToken{
+		Id:     "at_1",
+		Token:  "at_1_token",
+		UserId: u.Id,
+	}
+	kt := KeyringToken{
+		KeyringType: "keyring",
+		TokenName:   "token",
+		AuthTokenId: at.Id,
+	}
+	atMap := map[ringToken]*authtokens.AuthToken{
+		{kt.KeyringType, kt.TokenName}: at,
+	}
+	r, err := NewRepository(ctx, s, &sync.Map{}, mapBasedAuthTokenKeyringLookup(atMap), sliceBasedAuthTokenBoundaryReader(maps.Values(atMap)))
+	require.NoError(t, err)
+	require.NoError(t, r.AddKeyringToken(ctx, addr, kt))
+
+	hs := [][]*hosts.Host{
+		{
+			host("1"),
+			host("2"),
+		},
+		{
+			host("3"),
+		},
+	}
+
+	err = r.refreshHosts(ctx, &u, map[AuthToken]string{{Id: "id"}: "something"},
+		WithHostRetrievalFunc(testStaticResourceRetrievalFunc(t, hs, [][]string{nil, nil})))
+	assert.NoError(t, err)
+
+	got, err := r.ListHosts(ctx, at.Id)
+	require.NoError(t, err)
+	assert.Len(t, got, 2)
+
+	// Refreshing again uses the refresh token and get additional hosts, appending
+	// them to the response
+	err = r.refreshHosts(ctx, &u, map[AuthToken]string{{Id: "id"}: "something"},
+		WithHostRetrievalFunc(testStaticResourceRetrievalFunc(t, hs, [][]string{nil, nil})))
+	assert.NoError(t, err)
+
+	got, err = r.ListHosts(ctx, at.Id)
+	require.NoError(t, err)
+	assert.Len(t, got, 3)
+
+	// Refreshing again wont return any more resources, but also none should be
+	// removed
+	require.NoError(t, r.refreshHosts(ctx, &u, map[AuthToken]string{{Id: "id"}: "something"},
+		WithHostRetrievalFunc(testStaticResourceRetrievalFunc(t, hs, [][]string{nil, nil}))))
+
+	got, err = r.ListHosts(ctx, at.Id)
+	require.NoError(t, err)
+	assert.Len(t, got, 3)
+
+	// Refresh again with the refresh token being reported as invalid.
+	require.NoError(t, r.refreshHosts(ctx, &u, map[AuthToken]string{{Id: "id"}: "something"},
+		WithHostRetrievalFunc(testErroringForRefreshTokenRetrievalFunc(t, hs[0]))))
+
+	got, err = r.ListHosts(ctx, at.Id)
+	require.NoError(t, err)
+	assert.Len(t, got, 2)
+}
+
+func TestRepository_ListHosts(t *testing.T) {
+	ctx := context.Background()
+	s, err := cachedb.Open(ctx)
+	require.NoError(t, err)
+
+	addr := "address"
+	u1 := &user{
+		Id:      "u1",
+		Address: addr,
+	}
+	at1 := &authtokens.AuthToken{
+		Id:     "at_1",
+		Token:  "at_1_token",
+		UserId: u1.Id,
+	}
+	kt1 := KeyringToken{KeyringType: "k1", TokenName: "t1", AuthTokenId: at1.Id}
+
+	u2 := &user{
+		Id:      "u2",
+		Address: addr,
+	}
+	at2 := &authtokens.AuthToken{
+		Id:     "at_2",
+		Token:  "at_2_token",
+		UserId: u2.Id,
+	}
+	kt2 := KeyringToken{KeyringType: "k2", TokenName: "t2", AuthTokenId: at2.Id}
+	atMap := map[ringToken]*authtokens.AuthToken{
+		{"k1", "t1"}: at1,
+		{"k2", "t2"}: at2,
+	}
+	r, err := NewRepository(ctx, s, &sync.Map{}, mapBasedAuthTokenKeyringLookup(atMap), sliceBasedAuthTokenBoundaryReader(maps.Values(atMap)))
+	require.NoError(t, err)
+	require.NoError(t, r.AddKeyringToken(ctx, addr, kt1))
+	require.NoError(t, r.AddKeyringToken(ctx, addr, kt2))
+
+	t.Run("token is missing", func(t *testing.T) {
+		l, err := r.ListHosts(ctx, "")
+		assert.Nil(t, l)
+		assert.ErrorContains(t, err, "auth token id is missing")
+	})
+
+	hs := []*hosts.Host{
+		host("1"),
+		host("2"),
+		host("3"),
+	}
+	require.NoError(t, r.refreshHosts(ctx, u1, map[AuthToken]string{{Id: "id"}: "something"},
+		WithHostRetrievalFunc(testStaticResourceRetrievalFunc(t, [][]*hosts.Host{hs}, [][]string{nil}))))
+
+	t.Run("wrong user gets no hosts", func(t *testing.T) {
+		l, err := r.ListHosts(ctx, kt2.AuthTokenId)
+		assert.NoError(t, err)
+		assert.Empty(t, l)
+	})
+	t.Run("correct token gets hosts", func(t *testing.T) {
+		l, err := r.ListHosts(ctx, kt1.AuthTokenId)
+		assert.NoError(t, err)
+		assert.Len(t, l, len(hs))
+		assert.ElementsMatch(t, l, hs)
+	})
+}
+
+func TestRepository_QueryHosts(t *testing.T) {
+	ctx := context.Background()
+	s, err := cachedb.Open(ctx)
+	require.NoError(t, err)
+
+	addr := "address"
+	u1 := &user{
+		Id:      "u1",
+		Address: addr,
+	}
+	at1 := &authtokens.AuthToken{
+		Id:     "at_1",
+		Token:  "at_1_token",
+		UserId: u1.Id,
+	}
+	kt1 := KeyringToken{KeyringType: "k1", TokenName: "t1", AuthTokenId: at1.Id}
+
+	u2 := &user{
+		Id:      "u2",
+		Address: addr,
+	}
+	at2 := &authtokens.AuthToken{
+		Id:     "at_2",
+		Token:  "at_2_token",
+		UserId: u2.Id,
+	}
+	kt2 := KeyringToken{KeyringType: "k2", TokenName: "t2", AuthTokenId: at2.Id}
+	atMap := map[ringToken]*authtokens.AuthToken{
+		{"k1", "t1"}: at1,
+		{"k2", "t2"}: at2,
+	}
+	r, err := NewRepository(ctx, s, &sync.Map{}, mapBasedAuthTokenKeyringLookup(atMap), sliceBasedAuthTokenBoundaryReader(maps.Values(atMap)))
+	require.NoError(t, err)
+	require.NoError(t, r.AddKeyringToken(ctx, addr, kt1))
+	require.NoError(t, r.AddKeyringToken(ctx, addr, kt2))
+
+	query := `(name % 'name1' or name % 'name2') and host_catalog_id = "hcst_123"`
+
+	errorCases := []struct {
+		name        string
+		t           string
+		query       string
+		errContains string
+	}{
+		{
+			name:        "auth token id is missing",
+			t:           "",
+			query:       query,
+			errContains: "auth token id is missing",
+		},
+		{
+			name:        "query is missing",
+			t:           "token id",
+			errContains: "query is missing",
+		},
+	}
+	for _, tc := range errorCases {
+		t.Run(tc.name, func(t *testing.T) {
+			l, err := r.QueryHosts(ctx, tc.t, tc.query)
+			assert.Nil(t, l)
+			assert.ErrorContains(t, err, tc.errContains)
+		})
+	}
+
+	hs := []*hosts.Host{
+		{
+			Id:            "hst_1",
+			Name:          "name1",
+			HostCatalogId: "hcst_123",
+			Type:          "static",
+		},
+		{
+			Id:            "hst_2",
+			Name:          "name2",
+			HostCatalogId: "hcst_123",
+			Type:          "static",
+		},
+		{
+			Id:            "hst_3",
+			Name:          "name3",
+			HostCatalogId: "hcst_123",
+			Type:          "static",
+		},
+	}
+	require.NoError(t, r.refreshHosts(ctx, u1, map[AuthToken]string{{Id: "id"}: "something"},
+		WithHostRetrievalFunc(testStaticResourceRetrievalFunc(t, [][]*hosts.Host{hs}, [][]string{nil}))))
+
+	t.Run("wrong token gets no hosts", func(t *testing.T) {
+		l, err := r.QueryHosts(ctx, kt2.AuthTokenId, query)
+		assert.NoError(t, err)
+		assert.Empty(t, l)
+	})
+	t.Run("correct token gets hosts", func(t *testing.T) {
+		l, err := r.QueryHosts(ctx, kt1.AuthTokenId, query)
+		assert.NoError(t, err)
+		assert.Len(t, l, 2)
+		assert.ElementsMatch(t, l, hs[0:2])
+	})
+}
+
+func TestDefaultHostRetrievalFunc(t *testing.T) {
+	oldDur := globals.RefreshReadLookbackDuration
+	globals.RefreshReadLookbackDuration = 0
+	t.Cleanup(func() {
+		globals.RefreshReadLookbackDuration = oldDur
+	})
+
+	tc := controller.NewTestController(t, nil)
+	tc.Client().SetToken(tc.Token().Token)
+	hc, err := hostcatalogs.NewClient(tc.Client()).Create(tc.Context(), "static", "p_1234567890")
+	require.NoError(t, err)
+	require.NotNil(t, hc)
+	h1, err := hosts.NewClient(tc.Client()).Create(tc.Context(), hc.Item.Id, hosts.WithName("h1"), hosts.WithStaticHostAddress("address"))
+	require.NoError(t, err)
+	require.NotNil(t, h1)
+
+	got, removed, refTok, err := defaultHostFunc(tc.Context(), tc.ApiAddrs()[0], tc.Token().Token, "")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, refTok)
+	assert.Empty(t, removed)
+	found := false
+	for _, h := range got {
+		if h.Id == h1.Item.Id {
+			found = true
+		}
+	}
+	assert.True(t, found, "expected to find host %s in list", h1.Item.Id)
+
+	got2, removed2, refTok2, err := defaultHostFunc(tc.Context(), tc.ApiAddrs()[0], tc.Token().Token, refTok)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, refTok2)
+	assert.Empty(t, removed2)
+	assert.Empty(t, got2)
+}
@@ -0,0 +1,152 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/boundary/api/aliases"
+	"github.com/hashicorp/boundary/api/hosts"
+	"github.com/hashicorp/boundary/api/sessions"
+	"github.com/hashicorp/boundary/api/storagebuckets"
+	"github.com/hashicorp/boundary/api/targets"
+	"github.com/hashicorp/boundary/internal/db"
+	"github.com/hashicorp/boundary/internal/errors"
+	"github.com/hashicorp/boundary/internal/types/resource"
+	"github.com/hashicorp/boundary/internal/util"
+)
+
+// Refresh replaces the resources of the provided type cached for the provided
+// user with the provided items, the same way a full refresh from boundary
+// does. The items must be the slice type returned by the api client for that
+// resource, for example []*targets.Target for resource.Target. Targets without
+// a valid id are skipped, and an error wrapping ErrSkippedInvalidIds is returned
+// after the rest have been cached. An error is returned if the resource type is
+// not cached or the items are not of the expected type.
+func (r *Repository) Refresh(ctx context.Context, u *user, typ resource.Type, items any) error {
+	const op = "cache.(Repository).Refresh"
+	if err := r.checkOpen(ctx, op); err != nil {
+		return err
+	}
+	switch {
+	case util.IsNil(u):
+		return errors.New(ctx, errors.InvalidParameter, op, "user is nil")
+	case u.Id == "":
+		return errors.New(ctx, errors.InvalidParameter, op, "user id is missing")
+	}
+
+	// replaceFn replaces the cached resources with the items.
+	var replaceFn func(db.Reader, db.Writer, time.Time) error
+	// refreshed is set by replaceFn when replacing targets, whose changes are
+	// reported to the repository's event emitter like a refresh's are.
+	var refreshed *TargetsRefreshedEvent
+	// skippedIds are the ids of the targets which weren't cached because they
+	// aren't valid, which are reported once the rest have been cached.
+	var skippedIds []string
+	switch typ {
+	case resource.Target:
+		in, ok := items.([]*targets.Target)
+		if !ok {
+			return errors.New(ctx, errors.InvalidParameter, op, fmt.Sprintf("unexpected items type %T for %s", items, typ))
+		}
+		var err error
+		if in, skippedIds, err = partitionTargetsById(ctx, in, resource.Target.Prefixes()); err != nil {
+			return errors.Wrap(ctx, err, op)
+		}
+		replaceFn = func(reader db.Reader, w db.Writer, now time.Time) error {
+			cachedItems, err := cachedTargetItems(ctx, reader, u)
+			if err != nil {
+				return err
+			}
+			counts, err := syncTargets(ctx, w, u, in, cachedItems, now)
+			if err != nil {
+				return err
+			}
+			refreshed = &TargetsRefreshedEvent{UserId: u.Id, Added: counts.Added, Updated: counts.Updated, Removed: counts.Removed}
+			return nil
+		}
+	case resource.Session:
+		in, ok := items.([]*sessions.Session)
+		if !ok {
+			return errors.New(ctx, errors.InvalidParameter, op, fmt.Sprintf("unexpected items type %T for %s", items, typ))
+		}
+		replaceFn = func(_ db.Reader, w db.Writer, _ time.Time) error {
+			if err := deleteCachedResources(ctx, w, u, "session"); err != nil {
+				return err
+			}
+			return upsertSessions(ctx, w, u, in)
+		}
+	case resource.Host:
+		in, ok := items.([]*hosts.Host)
+		if !ok {
+			return errors.New(ctx, errors.InvalidParameter, op, fmt.Sprintf("unexpected items type %T for %s", items, typ))
+		}
+		replaceFn = func(_ db.Reader, w db.Writer, _ time.Time) error {
+			if err := deleteCachedResources(ctx, w, u, "host"); err != nil {
+				return err
+			}
+			return upsertHosts(ctx, w, u, in)
+		}
+	case resource.StorageBucket:
+		in, ok := items.([]*storagebuckets.StorageBucket)
+		if !ok {
+			return errors.New(ctx, errors.InvalidParameter, op, fmt.Sprintf("unexpected items type %T for %s", items, typ))
+		}
+		replaceFn = func(_ db.Reader, w db.Writer, _ time.Time) error {
+			if err := deleteCachedResources(ctx, w, u, "storage_bucket"); err != nil {
+				return err
+			}
+			return upsertStorageBuckets(ctx, w, u, in)
+		}
+	case resource.Alias:
+		in, ok := items.([]*aliases.Alias)
+		if !ok {
+			return errors.New(ctx, errors.InvalidParameter, op, fmt.Sprintf("unexpected items type %T for %s", items, typ))
+		}
+		replaceFn = func(_ db.Reader, w db.Writer, _ time.Time) error {
+			if err := deleteCachedResources(ctx, w, u, "alias"); err != nil {
+				return err
+			}
+			return upsertAliases(ctx, w, u, in)
+		}
+	default:
+		return errors.New(ctx, errors.InvalidParameter, op, fmt.Sprintf("unsupported resource type %s", typ))
+	}
+	resourceType, _ := resourceTypeFromResource(typ)
+
+	unlock := r.lockUser(u.Id)
+	defer unlock()
+
+	_, err := r.rw.DoTx(ctx, db.StdRetryCnt, db.ExpBackoff{}, func(reader db.Reader, w db.Writer) error {
+		now := r.clock()
+		if err := replaceFn(reader, w, now); err != nil {
+			return err
+		}
+		return upsertRefreshTime(ctx, w, u, resourceType, now)
+	})
+	if err != nil {
+		return errors.Wrap(ctx, err, op)
+	}
+	if refreshed != nil {
+		r.emitter.TargetsRefreshed(ctx, refreshed)
+	}
+	if err := r.skippedTargetsError(ctx, u, skippedIds); err != nil {
+		return errors.Wrap(ctx, err, op)
+	}
+	return nil
+}
+
+// deleteCachedResources deletes all the resources cached for the provided user
+// from the provided table.
+func deleteCachedResources(ctx context.Context, w db.Writer, u *user, table string) error {
+	const op = "cache.deleteCachedResources"
+	if _, err := w.Exec(ctx, fmt.Sprintf("delete from %s where fk_user_id = @fk_user_id", table),
+		[]any{sql.Named("fk_user_id", u.Id)}); err != nil {
+		return errors.Wrap(ctx, err, op)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package cache
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/boundary/api/authtokens"
+	"github.com/hashicorp/boundary/api/scopes"
+	"github.com/hashicorp/boundary/api/sessions"
+	"github.com/hashicorp/boundary/api/targets"
+	cachedb "github.com/hashicorp/boundary/internal/clientcache/internal/db"
+	"github.com/hashicorp/boundary/internal/errors"
+	"github.com/hashicorp/boundary/internal/types/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_Refresh(t *testing.T) {
+	ctx := context.Background()
+	s, err := cachedb.Open(ctx)
+	require.NoError(t, err)
+
+	addr := "address"
+	u := &user{
+		Id:      "u1",
+		Address: addr,
+	}
+	at := &authtokens.AuthToken{
+		Id:     "at_1",
+		Token:  "at_1_token",
+		UserId: u.Id,
+	}
+	kt := KeyringToken{KeyringType: "k", TokenName: "t", AuthTokenId: at.Id}
+	atMap := map[ringToken]*authtokens.AuthToken{
+		{kt.KeyringType, kt.TokenName}: at,
+	}
+	now := time.Now().Truncate(time.Millisecond)
+	r, err := NewRepository(ctx, s, &sync.Map{}, mapBasedAuthTokenKeyringLookup(atMap), sliceBasedAuthTokenBoundaryReader([]*authtokens.AuthToken{at}),
+		withClock(func() time.Time { return now }))
+	require.NoError(t, err)
+	require.NoError(t, r.AddKeyringToken(ctx, addr, kt))
+
+	t.Run("nil user", func(t *testing.T) {
+		err := r.Refresh(ctx, nil, resource.Target, []*targets.Target{target("1")})
+		assert.Truef(t, errors.Match(errors.T(errors.InvalidParameter), err), "unexpected error: %v", err)
+	})
+	t.Run("target", func(t *testing.T) {
+		ts := []*targets.Target{target("1"), target("2")}
+		require.NoError(t, r.Refresh(ctx, u, resource.Target, ts))
+
+		got, err := r.ListTargets(ctx, at.Id, WithSort("id", AscendingSortDirection))
+		require.NoError(t, err)
+		require.Len(t, got, 2)
+		assert.Equal(t, ts[0].Id, got[0].Id)
+		assert.Equal(t, ts[1].Id, got[1].Id)
+
+		last, err := r.LastRefresh(ctx, at.Id, resource.Target)
+		require.NoError(t, err)
+		assert.True(t, last.Equal(now), "expected %s to equal %s", last, now)
+	})
+	t.Run("target replaces the cached targets", func(t *testing.T) {
+		now = now.Add(time.Minute)
+		ts := []*targets.Target{target("2"), target("3"), {Id: "hst_1234567890", Name: "not a target"}}
+		err := r.Refresh(ctx, u, resource.Target, ts)
+		assert.ErrorIs(t, err, ErrSkippedInvalidIds)
+		assert.ErrorContains(t, err, "hst_1234567890")
+
+		got, err := r.ListTargets(ctx, at.Id)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, ts[:2], got)
+
+		last, err := r.LastRefresh(ctx, at.Id, resource.Target)
+		require.NoError(t, err)
+		assert.True(t, last.Equal(now), "expected %s to equal %s", last, now)
+	})
+	t.Run("session replaces the cached sessions", func(t *testing.T) {
+		require.NoError(t, r.Refresh(ctx, u, resource.Session, []*sessions.Session{session("1"), session("2")}))
+		require.NoError(t, r.Refresh(ctx, u, resource.Session, []*sessions.Session{session("2")}))
+
+		got, err := r.ListSessions(ctx, at.Id)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []*sessions.Session{session("2")}, got)
+	})
+	t.Run("mismatched items", func(t *testing.T) {
+		err := r.Refresh(ctx, u, resource.Target, []*sessions.Session{session("1")})
+		assert.Truef(t, errors.Match(errors.T(errors.InvalidParameter), err), "unexpected error: %v", err)
+	})
+	t.Run("unsupported scope", func(t *testing.T) {
+		err := r.Refresh(ctx, u, resource.Scope, []*scopes.Scope{{Id: "p_1"}})
+		assert.Truef(t, errors.Match(errors.T(errors.InvalidParameter), err), "unexpected error: %v", err)
+		assert.ErrorContains(t, err, "unsupported resource type")
+	})
+}
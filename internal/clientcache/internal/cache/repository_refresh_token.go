@@ -7,10 +7,12 @@ import (
 	"context"
 	"database/sql"
 	stderrors "errors"
+	"fmt"
 	"time"
 
 	"github.com/hashicorp/boundary/internal/db"
 	"github.com/hashicorp/boundary/internal/errors"
+	"github.com/hashicorp/boundary/internal/types/resource"
 	"github.com/hashicorp/boundary/internal/util"
 )
 
@@ -105,6 +107,50 @@ func (r *Repository) lookupRefreshToken(ctx context.Context, u *user, resourceTy
 	return rt, nil
 }
 
+// LastRefresh returns the time the resources of the provided type were last
+// refreshed for the user associated with the provided auth token id. If the
+// resources have never been refreshed the zero time is returned without an
+// error.
+func (r *Repository) LastRefresh(ctx context.Context, authTokenId string, resType resource.Type) (time.Time, error) {
+	const op = "cache.(Repository).LastRefresh"
+	if err := r.checkOpen(ctx, op); err != nil {
+		return time.Time{}, err
+	}
+	switch {
+	case authTokenId == "":
+		return time.Time{}, errors.New(ctx, errors.InvalidParameter, op, "auth token id is missing")
+	}
+	rt, ok := resourceTypeFromResource(resType)
+	if !ok {
+		return time.Time{}, errors.New(ctx, errors.InvalidParameter, op, fmt.Sprintf("resource type %q is not cached", resType.String()))
+	}
+	at, err := r.LookupToken(ctx, authTokenId)
+	if err != nil {
+		return time.Time{}, errors.Wrap(ctx, err, op)
+	}
+	if at == nil {
+		return time.Time{}, errors.New(ctx, errors.NotFound, op, "auth token not found")
+	}
+	u, err := r.lookupUser(ctx, at.UserId)
+	if err != nil {
+		return time.Time{}, errors.Wrap(ctx, err, op)
+	}
+	if u == nil {
+		return time.Time{}, errors.New(ctx, errors.NotFound, op, "user not found")
+	}
+	rft := &refreshTime{
+		UserId:       u.Id,
+		ResourceType: rt,
+	}
+	if err := r.rw.LookupById(ctx, rft); err != nil {
+		if errors.IsNotFoundError(err) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, errors.Wrap(ctx, err, op)
+	}
+	return rft.RefreshTime, nil
+}
+
 // listRefreshTokens returns all refresh tokens associated with a specific user
 func (r *Repository) listRefreshTokens(ctx context.Context, u *user) ([]*refreshToken, error) {
 	const op = "cache.(Repository).listRefreshTokens"
@@ -125,6 +171,9 @@ func (r *Repository) listRefreshTokens(ctx context.Context, u *user) ([]*refresh
 // deleteRefreshToken deletes the refresh token for the provided user and resource type
 func (r *Repository) deleteRefreshToken(ctx context.Context, u *user, rType resourceType) error {
 	const op = "cache.(Repository).deleteRefreshToken"
+	if err := r.checkOpen(ctx, op); err != nil {
+		return err
+	}
 	switch {
 	case util.IsNil(u):
 		return errors.New(ctx, errors.InvalidParameter, op, "user is nil")
@@ -215,6 +264,37 @@ func upsertRefreshToken(ctx context.Context, writer db.Writer, u *user, rt resou
 	return nil
 }
 
+// upsertRefreshTime records the provided time as the last time the resources
+// of the provided type were refreshed for the provided user.
+func upsertRefreshTime(ctx context.Context, writer db.Writer, u *user, rt resourceType, t time.Time) error {
+	const op = "cache.upsertRefreshTime"
+	switch {
+	case util.IsNil(writer):
+		return errors.New(ctx, errors.InvalidParameter, op, "writer is nil")
+	case !writer.IsTx(ctx):
+		return errors.New(ctx, errors.InvalidParameter, op, "writer isn't in a transaction")
+	case util.IsNil(u):
+		return errors.New(ctx, errors.InvalidParameter, op, "user is nil")
+	case u.Id == "":
+		return errors.New(ctx, errors.InvalidParameter, op, "user id is empty")
+	case !rt.valid():
+		return errors.New(ctx, errors.InvalidParameter, op, "resource type is invalid")
+	}
+	rft := &refreshTime{
+		UserId:       u.Id,
+		ResourceType: rt,
+		RefreshTime:  t,
+	}
+	onConflict := &db.OnConflict{
+		Target: db.Columns{"user_id", "resource_type"},
+		Action: db.SetColumns([]string{"refresh_time"}),
+	}
+	if err := writer.Create(ctx, rft, db.WithOnConflict(onConflict)); err != nil {
+		return errors.Wrap(ctx, err, op)
+	}
+	return nil
+}
+
 type resourceType string
 
 const (
@@ -222,11 +302,33 @@ const (
 	targetResourceType  resourceType = "target"
 	sessionResourceType resourceType = "session"
 	aliasResourceType   resourceType = "alias"
+	hostResourceType    resourceType = "host"
+	// storageBucketResourceType matches the name of the table the storage
+	// buckets are cached in.
+	storageBucketResourceType resourceType = "storage_bucket"
 )
 
+// resourceTypeFromResource returns the resourceType used by the cache for the
+// provided boundary resource type and whether or not that type is cached.
+func resourceTypeFromResource(t resource.Type) (resourceType, bool) {
+	switch t {
+	case resource.Alias:
+		return aliasResourceType, true
+	case resource.Target:
+		return targetResourceType, true
+	case resource.Session:
+		return sessionResourceType, true
+	case resource.Host:
+		return hostResourceType, true
+	case resource.StorageBucket:
+		return storageBucketResourceType, true
+	}
+	return unknownResourceType, false
+}
+
 func (r resourceType) valid() bool {
 	switch r {
-	case aliasResourceType, targetResourceType, sessionResourceType:
+	case aliasResourceType, targetResourceType, sessionResourceType, hostResourceType, storageBucketResourceType:
 		return true
 	}
 	return false
@@ -243,3 +345,13 @@ type refreshToken struct {
 func (*refreshToken) TableName() string {
 	return "refresh_token"
 }
+
+type refreshTime struct {
+	UserId       string       `gorm:"primaryKey"`
+	ResourceType resourceType `gorm:"primaryKey"`
+	RefreshTime  time.Time
+}
+
+func (*refreshTime) TableName() string {
+	return "refresh_time"
+}
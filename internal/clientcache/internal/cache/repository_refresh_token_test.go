@@ -10,8 +10,11 @@ import (
 	"time"
 
 	"github.com/hashicorp/boundary/api/authtokens"
+	"github.com/hashicorp/boundary/api/targets"
 	cachedb "github.com/hashicorp/boundary/internal/clientcache/internal/db"
 	"github.com/hashicorp/boundary/internal/db"
+	"github.com/hashicorp/boundary/internal/errors"
+	"github.com/hashicorp/boundary/internal/types/resource"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -221,6 +224,90 @@ func TestLookupRefreshToken(t *testing.T) {
 	})
 }
 
+func TestLastRefresh(t *testing.T) {
+	ctx := context.Background()
+	s, err := cachedb.Open(ctx)
+	require.NoError(t, err)
+
+	addr := "address"
+	u := &user{
+		Id:      "u1",
+		Address: addr,
+	}
+	at := &authtokens.AuthToken{
+		Id:     "at_1",
+		Token:  "at_1_token",
+		UserId: u.Id,
+	}
+	kt := KeyringToken{KeyringType: "k", TokenName: "t", AuthTokenId: at.Id}
+	atMap := map[ringToken]*authtokens.AuthToken{
+		{kt.KeyringType, kt.TokenName}: at,
+	}
+	now := time.Now().Truncate(time.Millisecond)
+	clock := func() time.Time { return now }
+	r, err := NewRepository(ctx, s, &sync.Map{}, mapBasedAuthTokenKeyringLookup(atMap), sliceBasedAuthTokenBoundaryReader([]*authtokens.AuthToken{at}), withClock(clock))
+	require.NoError(t, err)
+	require.NoError(t, r.AddKeyringToken(ctx, addr, kt))
+
+	t.Run("missing auth token id", func(t *testing.T) {
+		_, err := r.LastRefresh(ctx, "", resource.Target)
+		assert.Truef(t, errors.Match(errors.T(errors.InvalidParameter), err), "unexpected error: %v", err)
+	})
+	t.Run("uncached resource type", func(t *testing.T) {
+		_, err := r.LastRefresh(ctx, at.Id, resource.Role)
+		assert.Truef(t, errors.Match(errors.T(errors.InvalidParameter), err), "unexpected error: %v", err)
+	})
+	t.Run("unknown auth token", func(t *testing.T) {
+		_, err := r.LastRefresh(ctx, "at_unknown", resource.Target)
+		assert.Truef(t, errors.Match(errors.T(errors.NotFound), err), "unexpected error: %v", err)
+	})
+
+	got, err := r.LastRefresh(ctx, at.Id, resource.Target)
+	require.NoError(t, err)
+	assert.True(t, got.IsZero())
+
+	ts := [][]*targets.Target{
+		{target("1")},
+		{target("2")},
+	}
+	retFn := testStaticResourceRetrievalFunc(t, ts, [][]string{nil, nil})
+	_, err = r.refreshTargets(ctx, u, map[AuthToken]string{{Id: "id"}: "something"}, WithTargetRetrievalFunc(retFn))
+	require.NoError(t, err)
+	got, err = r.LastRefresh(ctx, at.Id, resource.Target)
+	require.NoError(t, err)
+	assert.True(t, got.Equal(now), "expected %s to equal %s", got, now)
+
+	now = now.Add(time.Minute)
+	_, err = r.refreshTargets(ctx, u, map[AuthToken]string{{Id: "id"}: "something"}, WithTargetRetrievalFunc(retFn))
+	require.NoError(t, err)
+	got, err = r.LastRefresh(ctx, at.Id, resource.Target)
+	require.NoError(t, err)
+	assert.True(t, got.Equal(now), "expected %s to equal %s", got, now)
+
+	// A full refresh which doesn't return a refresh token is still a refresh.
+	now = now.Add(time.Minute)
+	_, err = r.refreshTargets(ctx, u, map[AuthToken]string{{Id: "id"}: "something"},
+		WithTargetRetrievalFunc(testStaticResourceRetrievalFunc[*targets.Target](t, nil, nil)))
+	require.NoError(t, err)
+	got, err = r.LastRefresh(ctx, at.Id, resource.Target)
+	require.NoError(t, err)
+	assert.True(t, got.Equal(now), "expected %s to equal %s", got, now)
+
+	// So is a refresh against a controller which doesn't support refresh tokens.
+	now = now.Add(time.Minute)
+	_, err = r.refreshTargets(ctx, u, map[AuthToken]string{{Id: "id"}: "something"},
+		WithTargetRetrievalFunc(testNoRefreshRetrievalFunc[*targets.Target](t)))
+	require.ErrorIs(t, err, ErrRefreshNotSupported)
+	got, err = r.LastRefresh(ctx, at.Id, resource.Target)
+	require.NoError(t, err)
+	assert.True(t, got.Equal(now), "expected %s to equal %s", got, now)
+
+	// other resource types are tracked separately
+	got, err = r.LastRefresh(ctx, at.Id, resource.Session)
+	require.NoError(t, err)
+	assert.True(t, got.IsZero())
+}
+
 func TestDeleteRefreshTokens(t *testing.T) {
 	ctx := context.Background()
 	s, err := cachedb.Open(ctx)
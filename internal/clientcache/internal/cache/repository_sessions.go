@@ -51,6 +51,9 @@ func defaultSessionFunc(ctx context.Context, addr, authTok string, refreshTok Re
 // storage to retrieve and apply only the delta.
 func (r *Repository) refreshSessions(ctx context.Context, u *user, tokens map[AuthToken]string, opt ...Option) error {
 	const op = "cache.(Repository).refreshSessions"
+	if err := r.checkOpen(ctx, op); err != nil {
+		return err
+	}
 	switch {
 	case util.IsNil(u):
 		return errors.New(ctx, errors.InvalidParameter, op, "user is nil")
@@ -146,6 +149,9 @@ func (r *Repository) refreshSessions(ctx context.Context, u *user, tokens map[Au
 		default:
 			// controller supports caching, but doesn't have any resources
 		}
+		if err := upsertRefreshTime(ctx, w, u, resourceType, r.clock()); err != nil {
+			return err
+		}
 		return nil
 	})
 	if err != nil {
@@ -163,6 +169,9 @@ func (r *Repository) refreshSessions(ctx context.Context, u *user, tokens map[Au
 // token it will save that as well.
 func (r *Repository) checkCachingSessions(ctx context.Context, u *user, tokens map[AuthToken]string, opt ...Option) error {
 	const op = "cache.(Repository).checkSessionsForSearchability"
+	if err := r.checkOpen(ctx, op); err != nil {
+		return err
+	}
 	switch {
 	case util.IsNil(u):
 		return errors.New(ctx, errors.InvalidParameter, op, "user is nil")
@@ -248,7 +257,19 @@ func (r *Repository) checkCachingSessions(ctx context.Context, u *user, tokens m
 	return nil
 }
 
-// upsertSessions upserts the provided sessions to be stored for the provided user.
+// Session statuses the cache treats specially. Canceled sessions move to the
+// terminated status once they are cleaned up in boundary.
+const (
+	cancelingSessionStatus  = "canceling"
+	terminatedSessionStatus = "terminated"
+)
+
+// inactiveSessionStatuses are the statuses of sessions which have been
+// canceled or terminated and so are not returned when listing active sessions.
+var inactiveSessionStatuses = []string{cancelingSessionStatus, terminatedSessionStatus}
+
+// upsertSessions upserts the provided sessions to be stored for the provided
+// user. Terminated sessions are evicted from the cache instead of being stored.
 func upsertSessions(ctx context.Context, w db.Writer, u *user, in []*sessions.Session) error {
 	const op = "cache.upsertSessions"
 	switch {
@@ -261,6 +282,13 @@ func upsertSessions(ctx context.Context, w db.Writer, u *user, in []*sessions.Se
 	}
 
 	for _, s := range in {
+		if s.Status == terminatedSessionStatus {
+			if _, err := w.Exec(ctx, "delete from session where fk_user_id = @fk_user_id and id = @id",
+				[]any{sql.Named("fk_user_id", u.Id), sql.Named("id", s.Id)}); err != nil {
+				return errors.Wrap(ctx, err, op)
+			}
+			continue
+		}
 		item, err := json.Marshal(s)
 		if err != nil {
 			return errors.Wrap(ctx, err, op)
@@ -287,13 +315,26 @@ func upsertSessions(ctx context.Context, w db.Writer, u *user, in []*sessions.Se
 	return nil
 }
 
-func (r *Repository) ListSessions(ctx context.Context, authTokenId string) ([]*sessions.Session, error) {
+// ListSessions returns all the cached sessions for the user associated with
+// the provided auth token id. Supported options: WithActiveOnly
+func (r *Repository) ListSessions(ctx context.Context, authTokenId string, opt ...Option) ([]*sessions.Session, error) {
 	const op = "cache.(Repository).ListSessions"
+	if err := r.checkOpen(ctx, op); err != nil {
+		return nil, err
+	}
 	switch {
 	case authTokenId == "":
 		return nil, errors.New(ctx, errors.InvalidParameter, op, "auth token id is missing")
 	}
-	ret, err := r.searchSessions(ctx, "true", nil, withAuthTokenId(authTokenId))
+	opts, err := getOpts(opt...)
+	if err != nil {
+		return nil, errors.Wrap(ctx, err, op)
+	}
+	condition, args := "true", []any(nil)
+	if opts.withActiveOnly {
+		condition, args = "status not in (?)", []any{inactiveSessionStatuses}
+	}
+	ret, err := r.searchSessions(ctx, condition, args, withAuthTokenId(authTokenId))
 	if err != nil {
 		return nil, errors.Wrap(ctx, err, op)
 	}
@@ -302,6 +343,9 @@ func (r *Repository) ListSessions(ctx context.Context, authTokenId string) ([]*s
 
 func (r *Repository) QuerySessions(ctx context.Context, authTokenId, query string) ([]*sessions.Session, error) {
 	const op = "cache.(Repository).QuerySessions"
+	if err := r.checkOpen(ctx, op); err != nil {
+		return nil, err
+	}
 	switch {
 	case authTokenId == "":
 		return nil, errors.New(ctx, errors.InvalidParameter, op, "auth token id is missing")
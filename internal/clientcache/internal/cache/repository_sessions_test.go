@@ -365,6 +365,64 @@ func TestRepository_ListSessions(t *testing.T) {
 	})
 }
 
+func TestRepository_SessionStatusEviction(t *testing.T) {
+	ctx := context.Background()
+	s, err := cachedb.Open(ctx)
+	require.NoError(t, err)
+
+	addr := "address"
+	u := &user{
+		Id:      "u1",
+		Address: addr,
+	}
+	at := &authtokens.AuthToken{
+		Id:     "at_1",
+		Token:  "at_1_token",
+		UserId: u.Id,
+	}
+	kt := KeyringToken{KeyringType: "k", TokenName: "t", AuthTokenId: at.Id}
+	atMap := map[ringToken]*authtokens.AuthToken{
+		{kt.KeyringType, kt.TokenName}: at,
+	}
+	r, err := NewRepository(ctx, s, &sync.Map{}, mapBasedAuthTokenKeyringLookup(atMap), sliceBasedAuthTokenBoundaryReader(maps.Values(atMap)))
+	require.NoError(t, err)
+	require.NoError(t, r.AddKeyringToken(ctx, addr, kt))
+
+	active := &sessions.Session{Id: "s_1", Status: "active", TargetId: "ttcp_1", Type: "tcp"}
+	pending := &sessions.Session{Id: "s_2", Status: "pending", TargetId: "ttcp_1", Type: "tcp"}
+	terminated := &sessions.Session{Id: "s_1", Status: "terminated", TargetId: "ttcp_1", Type: "tcp"}
+	alreadyTerminated := &sessions.Session{Id: "s_3", Status: "terminated", TargetId: "ttcp_1", Type: "tcp"}
+	canceling := &sessions.Session{Id: "s_4", Status: "canceling", TargetId: "ttcp_1", Type: "tcp"}
+
+	// The first refresh does a full fetch and the second only applies the
+	// sessions which changed since the first.
+	opt := WithSessionRetrievalFunc(testStaticResourceRetrievalFunc(t,
+		[][]*sessions.Session{
+			{active, pending, alreadyTerminated, canceling},
+			{terminated},
+		},
+		[][]string{nil, nil},
+	))
+
+	require.NoError(t, r.refreshSessions(ctx, u, map[AuthToken]string{{Id: "id"}: "something"}, opt))
+	l, err := r.ListSessions(ctx, at.Id)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []*sessions.Session{active, pending, canceling}, l)
+	// Canceling sessions stay cached until they are terminated, but aren't
+	// active.
+	l, err = r.ListSessions(ctx, at.Id, WithActiveOnly(true))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []*sessions.Session{active, pending}, l)
+
+	require.NoError(t, r.refreshSessions(ctx, u, map[AuthToken]string{{Id: "id"}: "something"}, opt))
+	l, err = r.ListSessions(ctx, at.Id)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []*sessions.Session{pending, canceling}, l)
+	l, err = r.ListSessions(ctx, at.Id, WithActiveOnly(true))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []*sessions.Session{pending}, l)
+}
+
 func TestRepository_QuerySessions(t *testing.T) {
 	ctx := context.Background()
 	s, err := cachedb.Open(ctx)
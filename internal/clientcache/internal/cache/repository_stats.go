@@ -0,0 +1,116 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/boundary/internal/db"
+	"github.com/hashicorp/boundary/internal/errors"
+)
+
+// CacheStats contains aggregate information about the contents of the cache.
+type CacheStats struct {
+	Users         int
+	AuthTokens    int
+	KeyringTokens int
+	// ResourceCounts maps each cached resource type to the number of
+	// resources of that type cached across all users.
+	ResourceCounts map[string]int
+	// LastRefreshTimes maps each cached resource type to the most recent time
+	// resources of that type were refreshed for any user. Resource types which
+	// were never refreshed are not included.
+	LastRefreshTimes map[string]time.Time
+	// DbSize is the size of the cache's database in bytes.
+	DbSize int64
+}
+
+// cachedResourceTypes are the resource types which are stored in the cache,
+// each in a table named after the resource type.
+var cachedResourceTypes = []resourceType{
+	aliasResourceType,
+	hostResourceType,
+	sessionResourceType,
+	storageBucketResourceType,
+	targetResourceType,
+}
+
+// Stats returns aggregate information about the contents of the cache. It
+// only reads from the cache.
+func (r *Repository) Stats(ctx context.Context) (*CacheStats, error) {
+	const op = "cache.(Repository).Stats"
+	if err := r.checkOpen(ctx, op); err != nil {
+		return nil, err
+	}
+	ret := &CacheStats{
+		ResourceCounts:   make(map[string]int, len(cachedResourceTypes)),
+		LastRefreshTimes: make(map[string]time.Time, len(cachedResourceTypes)),
+	}
+
+	var err error
+	if ret.Users, err = r.count(ctx, "select count(*) from user"); err != nil {
+		return nil, errors.Wrap(ctx, err, op)
+	}
+	if ret.AuthTokens, err = r.count(ctx, "select count(*) from auth_token"); err != nil {
+		return nil, errors.Wrap(ctx, err, op)
+	}
+	if ret.KeyringTokens, err = r.count(ctx, "select count(*) from keyring_token"); err != nil {
+		return nil, errors.Wrap(ctx, err, op)
+	}
+	for _, rt := range cachedResourceTypes {
+		c, err := r.count(ctx, fmt.Sprintf("select count(*) from %s", rt))
+		if err != nil {
+			return nil, errors.Wrap(ctx, err, op)
+		}
+		ret.ResourceCounts[string(rt)] = c
+	}
+
+	var refreshTimes []*refreshTime
+	if err := r.rw.SearchWhere(ctx, &refreshTimes, "true", nil, db.WithLimit(-1)); err != nil {
+		return nil, errors.Wrap(ctx, err, op)
+	}
+	for _, rt := range refreshTimes {
+		if last, ok := ret.LastRefreshTimes[string(rt.ResourceType)]; !ok || rt.RefreshTime.After(last) {
+			ret.LastRefreshTimes[string(rt.ResourceType)] = rt.RefreshTime
+		}
+	}
+
+	rows, err := r.rw.Query(ctx, "select page_count * page_size from pragma_page_count(), pragma_page_size()", nil)
+	if err != nil {
+		return nil, errors.Wrap(ctx, err, op)
+	}
+	defer rows.Close()
+	if rows.Next() {
+		if err := rows.Scan(&ret.DbSize); err != nil {
+			return nil, errors.Wrap(ctx, err, op)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(ctx, err, op)
+	}
+	return ret, nil
+}
+
+// count runs the provided query, which must select a single count, and returns
+// the count.
+func (r *Repository) count(ctx context.Context, query string) (int, error) {
+	const op = "cache.(Repository).count"
+	rows, err := r.rw.Query(ctx, query, nil)
+	if err != nil {
+		return 0, errors.Wrap(ctx, err, op)
+	}
+	defer rows.Close()
+	var count int
+	if rows.Next() {
+		if err := rows.Scan(&count); err != nil {
+			return 0, errors.Wrap(ctx, err, op)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, errors.Wrap(ctx, err, op)
+	}
+	return count, nil
+}
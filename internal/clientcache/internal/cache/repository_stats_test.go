@@ -0,0 +1,113 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package cache
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/boundary/api/authtokens"
+	"github.com/hashicorp/boundary/api/sessions"
+	"github.com/hashicorp/boundary/api/targets"
+	cachedb "github.com/hashicorp/boundary/internal/clientcache/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_Stats(t *testing.T) {
+	ctx := context.Background()
+	s, err := cachedb.Open(ctx)
+	require.NoError(t, err)
+
+	addr := "address"
+	u1 := &user{
+		Id:      "u1",
+		Address: addr,
+	}
+	at1 := &authtokens.AuthToken{
+		Id:     "at_1",
+		Token:  "at_1_token",
+		UserId: u1.Id,
+	}
+	at2 := &authtokens.AuthToken{
+		Id:     "at_2",
+		Token:  "at_2_token",
+		UserId: u1.Id,
+	}
+	u2 := &user{
+		Id:      "u2",
+		Address: addr,
+	}
+	at3 := &authtokens.AuthToken{
+		Id:     "at_3",
+		Token:  "at_3_token",
+		UserId: u2.Id,
+	}
+	atMap := map[ringToken]*authtokens.AuthToken{
+		{"k", "t1"}: at1,
+		{"k", "t2"}: at2,
+	}
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	r, err := NewRepository(ctx, s, &sync.Map{}, mapBasedAuthTokenKeyringLookup(atMap),
+		sliceBasedAuthTokenBoundaryReader([]*authtokens.AuthToken{at1, at2, at3}),
+		withClock(func() time.Time { return now }))
+	require.NoError(t, err)
+
+	t.Run("empty", func(t *testing.T) {
+		got, err := r.Stats(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, got.Users)
+		assert.Zero(t, got.AuthTokens)
+		assert.Zero(t, got.KeyringTokens)
+		assert.Equal(t, map[string]int{"alias": 0, "host": 0, "session": 0, "storage_bucket": 0, "target": 0}, got.ResourceCounts)
+		assert.Empty(t, got.LastRefreshTimes)
+		assert.Positive(t, got.DbSize)
+	})
+
+	require.NoError(t, r.AddKeyringToken(ctx, addr, KeyringToken{KeyringType: "k", TokenName: "t1", AuthTokenId: at1.Id}))
+	require.NoError(t, r.AddKeyringToken(ctx, addr, KeyringToken{KeyringType: "k", TokenName: "t2", AuthTokenId: at2.Id}))
+	require.NoError(t, r.AddRawToken(ctx, addr, at3.Token))
+
+	targetsRetFn := testStaticResourceRetrievalFunc(t, [][]*targets.Target{{target("1"), target("2")}}, [][]string{nil})
+	_, err = r.refreshTargets(ctx, u1, map[AuthToken]string{{Id: "id"}: "something"}, WithTargetRetrievalFunc(targetsRetFn))
+	require.NoError(t, err)
+	_, err = r.refreshTargets(ctx, u2, map[AuthToken]string{{Id: "id"}: "something"},
+		WithTargetRetrievalFunc(testStaticResourceRetrievalFunc(t, [][]*targets.Target{{target("3")}}, [][]string{nil})))
+	require.NoError(t, err)
+	sessionsRefreshed := now
+	require.NoError(t, r.refreshSessions(ctx, u1, map[AuthToken]string{{Id: "id"}: "something"},
+		WithSessionRetrievalFunc(testStaticResourceRetrievalFunc(t, [][]*sessions.Session{{session("1")}}, [][]string{nil}))))
+
+	t.Run("populated", func(t *testing.T) {
+		got, err := r.Stats(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, got.Users)
+		assert.Equal(t, 3, got.AuthTokens)
+		assert.Equal(t, 2, got.KeyringTokens)
+		assert.Equal(t, map[string]int{"alias": 0, "host": 0, "session": 1, "storage_bucket": 0, "target": 3}, got.ResourceCounts)
+		require.Len(t, got.LastRefreshTimes, 2)
+		assert.True(t, got.LastRefreshTimes["target"].Equal(now), "expected %s to equal %s", got.LastRefreshTimes["target"], now)
+		assert.True(t, got.LastRefreshTimes["session"].Equal(now), "expected %s to equal %s", got.LastRefreshTimes["session"], now)
+		assert.Positive(t, got.DbSize)
+	})
+
+	t.Run("refresh without a new refresh token", func(t *testing.T) {
+		before, err := r.lookupRefreshToken(ctx, u1, targetResourceType)
+		require.NoError(t, err)
+
+		now = now.Add(time.Hour)
+		_, err = r.refreshTargets(ctx, u1, map[AuthToken]string{{Id: "id"}: "something"}, WithTargetRetrievalFunc(targetsRetFn))
+		require.NoError(t, err)
+		after, err := r.lookupRefreshToken(ctx, u1, targetResourceType)
+		require.NoError(t, err)
+		require.Equal(t, before.RefreshToken, after.RefreshToken)
+
+		got, err := r.Stats(ctx)
+		require.NoError(t, err)
+		assert.True(t, got.LastRefreshTimes["target"].Equal(now), "expected %s to equal %s", got.LastRefreshTimes["target"], now)
+		assert.True(t, got.LastRefreshTimes["session"].Equal(sessionsRefreshed), "expected %s to equal %s", got.LastRefreshTimes["session"], sessionsRefreshed)
+	})
+}
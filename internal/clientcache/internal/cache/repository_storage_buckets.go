@@ -0,0 +1,356 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+
+	"github.com/hashicorp/boundary/api"
+	"github.com/hashicorp/boundary/api/storagebuckets"
+	"github.com/hashicorp/boundary/internal/db"
+	"github.com/hashicorp/boundary/internal/errors"
+	"github.com/hashicorp/boundary/internal/event"
+	"github.com/hashicorp/boundary/internal/util"
+)
+
+// StorageBucketRetrievalFunc is a function that retrieves storage buckets
+// from the provided boundary addr using the provided token.
+type StorageBucketRetrievalFunc func(ctx context.Context, addr, authTok string, refreshTok RefreshTokenValue) (ret []*storagebuckets.StorageBucket, removedIds []string, refreshToken RefreshTokenValue, err error)
+
+func defaultStorageBucketFunc(ctx context.Context, addr, authTok string, refreshTok RefreshTokenValue) ([]*storagebuckets.StorageBucket, []string, RefreshTokenValue, error) {
+	const op = "cache.defaultStorageBucketFunc"
+	client, err := api.NewClient(&api.Config{
+		Addr:  addr,
+		Token: authTok,
+	})
+	if err != nil {
+		return nil, nil, "", errors.Wrap(ctx, err, op)
+	}
+	sbClient := storagebuckets.NewClient(client)
+	l, err := sbClient.List(ctx, "global", storagebuckets.WithRecursive(true), storagebuckets.WithListToken(string(refreshTok)))
+	if err != nil {
+		if api.ErrInvalidListToken.Is(err) {
+			return nil, nil, "", err
+		}
+		return nil, nil, "", errors.Wrap(ctx, err, op)
+	}
+	if l.ResponseType == "" {
+		return nil, nil, "", ErrRefreshNotSupported
+	}
+	return l.Items, l.RemovedIds, RefreshTokenValue(l.ListToken), nil
+}
+
+// refreshStorageBuckets attempts to refresh the storage buckets for the provided user
+// using the provided tokens. If available, it uses the refresh tokens in
+// storage to retrieve and apply only the delta.
+func (r *Repository) refreshStorageBuckets(ctx context.Context, u *user, tokens map[AuthToken]string, opt ...Option) error {
+	const op = "cache.(Repository).refreshStorageBuckets"
+	if err := r.checkOpen(ctx, op); err != nil {
+		return err
+	}
+	switch {
+	case util.IsNil(u):
+		return errors.New(ctx, errors.InvalidParameter, op, "user is nil")
+	case u.Id == "":
+		return errors.New(ctx, errors.InvalidParameter, op, "user id is missing")
+	}
+	const resourceType = storageBucketResourceType
+
+	opts, err := getOpts(opt...)
+	if err != nil {
+		return errors.Wrap(ctx, err, op)
+	}
+	if opts.withStorageBucketRetrievalFunc == nil {
+		opts.withStorageBucketRetrievalFunc = defaultStorageBucketFunc
+	}
+
+	var oldRefreshTokenVal RefreshTokenValue
+	oldRefreshToken, err := r.lookupRefreshToken(ctx, u, resourceType)
+	if err != nil {
+		return errors.Wrap(ctx, err, op)
+	}
+	if oldRefreshToken != nil {
+		oldRefreshTokenVal = oldRefreshToken.RefreshToken
+	}
+
+	// Find and use a token for retrieving storage buckets
+	var gotResponse bool
+	var resp []*storagebuckets.StorageBucket
+	var removedIds []string
+	var newRefreshToken RefreshTokenValue
+	var unsupportedCacheRequest bool
+	var retErr error
+	for at, t := range tokens {
+		resp, removedIds, newRefreshToken, err = opts.withStorageBucketRetrievalFunc(ctx, u.Address, t, oldRefreshTokenVal)
+		if api.ErrInvalidListToken.Is(err) {
+			event.WriteSysEvent(ctx, op, "old list token is no longer valid, starting new initial fetch", "user_id", u.Id)
+			if err := r.deleteRefreshToken(ctx, u, resourceType); err != nil {
+				return errors.Wrap(ctx, err, op)
+			}
+			// try again without the refresh token
+			oldRefreshToken = nil
+			resp, removedIds, newRefreshToken, err = opts.withStorageBucketRetrievalFunc(ctx, u.Address, t, "")
+		}
+		if err != nil {
+			if err == ErrRefreshNotSupported {
+				unsupportedCacheRequest = true
+			} else {
+				retErr = stderrors.Join(retErr, errors.Wrap(ctx, err, op, errors.WithMsg("for token %q", at.Id)))
+				continue
+			}
+		}
+		gotResponse = true
+		break
+	}
+	if retErr != nil {
+		if saveErr := r.saveError(r.serverCtx, u, resourceType, retErr); saveErr != nil {
+			return stderrors.Join(err, errors.Wrap(ctx, saveErr, op))
+		}
+	}
+	if !gotResponse {
+		return retErr
+	}
+
+	var numDeleted int
+	_, err = r.rw.DoTx(ctx, db.StdRetryCnt, db.ExpBackoff{}, func(_ db.Reader, w db.Writer) error {
+		var err error
+		switch {
+		case oldRefreshToken == nil || unsupportedCacheRequest:
+			if numDeleted, err = w.Exec(ctx, "delete from storage_bucket where fk_user_id = @fk_user_id",
+				[]any{sql.Named("fk_user_id", u.Id)}); err != nil {
+				return err
+			}
+		case len(removedIds) > 0:
+			if numDeleted, err = w.Exec(ctx, "delete from storage_bucket where fk_user_id = @fk_user_id and id in @ids",
+				[]any{sql.Named("fk_user_id", u.Id), sql.Named("ids", removedIds)}); err != nil {
+				return err
+			}
+		}
+		switch {
+		case unsupportedCacheRequest:
+			if err := upsertRefreshToken(ctx, w, u, resourceType, sentinelNoRefreshToken); err != nil {
+				return err
+			}
+		case newRefreshToken != "":
+			if err := upsertStorageBuckets(ctx, w, u, resp); err != nil {
+				return err
+			}
+			if err := upsertRefreshToken(ctx, w, u, resourceType, newRefreshToken); err != nil {
+				return err
+			}
+		default:
+			// controller supports caching, but doesn't have any resources
+		}
+		if err := upsertRefreshTime(ctx, w, u, resourceType, r.clock()); err != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(ctx, err, op)
+	}
+	if unsupportedCacheRequest {
+		return ErrRefreshNotSupported
+	}
+	event.WriteSysEvent(ctx, op, "storage buckets updated", "deleted", numDeleted, "upserted", len(resp), "user_id", u.Id)
+	return nil
+}
+
+// checkCachingStorageBuckets fetches all storage buckets for the provided user and sets the
+// cache to match the values returned. If the response includes a refresh
+// token it will save that as well.
+func (r *Repository) checkCachingStorageBuckets(ctx context.Context, u *user, tokens map[AuthToken]string, opt ...Option) error {
+	const op = "cache.(Repository).checkCachingStorageBuckets"
+	if err := r.checkOpen(ctx, op); err != nil {
+		return err
+	}
+	switch {
+	case util.IsNil(u):
+		return errors.New(ctx, errors.InvalidParameter, op, "user is nil")
+	case u.Id == "":
+		return errors.New(ctx, errors.InvalidParameter, op, "user id is missing")
+	}
+	const resourceType = storageBucketResourceType
+
+	opts, err := getOpts(opt...)
+	if err != nil {
+		return errors.Wrap(ctx, err, op)
+	}
+	if opts.withStorageBucketRetrievalFunc == nil {
+		opts.withStorageBucketRetrievalFunc = defaultStorageBucketFunc
+	}
+
+	// Find and use a token for retrieving storage buckets
+	var gotResponse bool
+	var resp []*storagebuckets.StorageBucket
+	var newRefreshToken RefreshTokenValue
+	var unsupportedCacheRequest bool
+	var retErr error
+	for at, t := range tokens {
+		resp, _, newRefreshToken, err = opts.withStorageBucketRetrievalFunc(ctx, u.Address, t, "")
+		if err != nil {
+			if err == ErrRefreshNotSupported {
+				unsupportedCacheRequest = true
+			} else {
+				retErr = stderrors.Join(retErr, errors.Wrap(ctx, err, op, errors.WithMsg("for token %q", at.Id)))
+				continue
+			}
+		}
+		gotResponse = true
+		break
+	}
+	if retErr != nil {
+		if saveErr := r.saveError(r.serverCtx, u, resourceType, retErr); saveErr != nil {
+			return stderrors.Join(err, errors.Wrap(ctx, saveErr, op))
+		}
+	}
+	if !gotResponse {
+		return retErr
+	}
+
+	var numDeleted int
+	_, err = r.rw.DoTx(ctx, db.StdRetryCnt, db.ExpBackoff{}, func(_ db.Reader, w db.Writer) error {
+		switch {
+		case unsupportedCacheRequest:
+			if err := upsertRefreshToken(ctx, w, u, resourceType, sentinelNoRefreshToken); err != nil {
+				return err
+			}
+		case newRefreshToken != "":
+			var err error
+			if numDeleted, err = w.Exec(ctx, "delete from storage_bucket where fk_user_id = @fk_user_id",
+				[]any{sql.Named("fk_user_id", u.Id)}); err != nil {
+				return err
+			}
+			if err := upsertStorageBuckets(ctx, w, u, resp); err != nil {
+				return err
+			}
+			if err := upsertRefreshToken(ctx, w, u, resourceType, newRefreshToken); err != nil {
+				return err
+			}
+		default:
+			// We know the controller supports caching, but doesn't have a
+			// refresh token so clear out any refresh token we have for this resource.
+			if err := deleteRefreshToken(ctx, w, u, resourceType); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(ctx, err, op)
+	}
+	if unsupportedCacheRequest {
+		return ErrRefreshNotSupported
+	}
+	event.WriteSysEvent(ctx, op, "storage buckets updated", "deleted", numDeleted, "upserted", len(resp), "user_id", u.Id)
+	return nil
+}
+
+// upsertStorageBuckets upserts the provided storage buckets to be stored for
+// the provided user.
+func upsertStorageBuckets(ctx context.Context, w db.Writer, u *user, in []*storagebuckets.StorageBucket) error {
+	const op = "cache.upsertStorageBuckets"
+	switch {
+	case util.IsNil(w):
+		return errors.New(ctx, errors.InvalidParameter, op, "writer is nil")
+	case !w.IsTx(ctx):
+		return errors.New(ctx, errors.InvalidParameter, op, "writer isn't in a transaction")
+	case util.IsNil(u):
+		return errors.New(ctx, errors.InvalidParameter, op, "user is nil")
+	}
+
+	for _, sb := range in {
+		item, err := json.Marshal(sb)
+		if err != nil {
+			return errors.Wrap(ctx, err, op)
+		}
+		newStorageBucket := &StorageBucket{
+			FkUserId: u.Id,
+			Id:       sb.Id,
+			Name:     sb.Name,
+			ScopeId:  sb.ScopeId,
+			Item:     string(item),
+		}
+		onConflict := db.OnConflict{
+			Target: db.Columns{"fk_user_id", "id"},
+			Action: db.SetColumns([]string{"name", "scope_id", "item"}),
+		}
+		if err := w.Create(ctx, newStorageBucket, db.WithOnConflict(&onConflict)); err != nil {
+			return errors.Wrap(ctx, err, op)
+		}
+	}
+	return nil
+}
+
+func (r *Repository) ListStorageBuckets(ctx context.Context, authTokenId string) ([]*storagebuckets.StorageBucket, error) {
+	const op = "cache.(Repository).ListStorageBuckets"
+	if err := r.checkOpen(ctx, op); err != nil {
+		return nil, err
+	}
+	switch {
+	case authTokenId == "":
+		return nil, errors.New(ctx, errors.InvalidParameter, op, "auth token id is missing")
+	}
+	ret, err := r.searchStorageBuckets(ctx, "true", nil, withAuthTokenId(authTokenId))
+	if err != nil {
+		return nil, errors.Wrap(ctx, err, op)
+	}
+	return ret, nil
+}
+
+func (r *Repository) searchStorageBuckets(ctx context.Context, condition string, searchArgs []any, opt ...Option) ([]*storagebuckets.StorageBucket, error) {
+	const op = "cache.(Repository).searchStorageBuckets"
+	switch {
+	case condition == "":
+		return nil, errors.New(ctx, errors.InvalidParameter, op, "condition is missing")
+	}
+
+	opts, err := getOpts(opt...)
+	if err != nil {
+		return nil, errors.Wrap(ctx, err, op)
+	}
+	switch {
+	case opts.withAuthTokenId != "" && opts.withUserId != "":
+		return nil, errors.New(ctx, errors.InvalidParameter, op, "both user id and auth token id were provided")
+	case opts.withAuthTokenId == "" && opts.withUserId == "":
+		return nil, errors.New(ctx, errors.InvalidParameter, op, "neither user id nor auth token id were provided")
+	case opts.withAuthTokenId != "":
+		condition = fmt.Sprintf("%s and fk_user_id in (select user_id from auth_token where id = ?)", condition)
+		searchArgs = append(searchArgs, opts.withAuthTokenId)
+	case opts.withUserId != "":
+		condition = fmt.Sprintf("%s and fk_user_id = ?", condition)
+		searchArgs = append(searchArgs, opts.withUserId)
+	}
+
+	var cachedStorageBuckets []*StorageBucket
+	if err := r.rw.SearchWhere(ctx, &cachedStorageBuckets, condition, searchArgs, db.WithLimit(-1)); err != nil {
+		return nil, errors.Wrap(ctx, err, op)
+	}
+
+	retStorageBuckets := make([]*storagebuckets.StorageBucket, 0, len(cachedStorageBuckets))
+	for _, cachedStorageBucket := range cachedStorageBuckets {
+		var sb storagebuckets.StorageBucket
+		if err := json.Unmarshal([]byte(cachedStorageBucket.Item), &sb); err != nil {
+			return nil, errors.Wrap(ctx, err, op)
+		}
+		retStorageBuckets = append(retStorageBuckets, &sb)
+	}
+	return retStorageBuckets, nil
+}
+
+type StorageBucket struct {
+	FkUserId string `gorm:"primaryKey"`
+	Id       string `gorm:"primaryKey"`
+	Name     string `gorm:"default:null"`
+	ScopeId  string `gorm:"default:null"`
+	Item     string `gorm:"default:null"`
+}
+
+func (*StorageBucket) TableName() string {
+	return "storage_bucket"
+}
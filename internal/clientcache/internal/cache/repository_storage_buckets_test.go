@@ -0,0 +1,213 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/boundary/api/authtokens"
+	"github.com/hashicorp/boundary/api/storagebuckets"
+	cachedb "github.com/hashicorp/boundary/internal/clientcache/internal/db"
+	"github.com/hashicorp/boundary/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/maps"
+)
+
+func storageBucket(suffix string) *storagebuckets.StorageBucket {
+	return &storagebuckets.StorageBucket{
+		Id:      fmt.Sprintf("sb_%s", suffix),
+		Name:    fmt.Sprintf("name_%s", suffix),
+		ScopeId: "global",
+	}
+}
+
+func TestRepository_refreshStorageBuckets(t *testing.T) {
+	ctx := context.Background()
+	s, err := cachedb.Open(ctx)
+	require.NoError(t, err)
+
+	addr := "address"
+	u := user{
+		Id:      "u1",
+		Address: addr,
+	}
+	at := &authtokens.AuthToken{
+		Id:     "at_1",
+		Token:  "at_1_token",
+		UserId: u.Id,
+	}
+	kt := KeyringToken{
+		KeyringType: "keyring",
+		TokenName:   "token",
+		AuthTokenId: at.Id,
+	}
+	atMap := map[ringToken]*authtokens.AuthToken{
+		{kt.KeyringType, kt.TokenName}: at,
+	}
+	r, err := NewRepository(ctx, s, &sync.Map{}, mapBasedAuthTokenKeyringLookup(atMap), sliceBasedAuthTokenBoundaryReader(maps.Values(atMap)))
+	require.NoError(t, err)
+	require.NoError(t, r.AddKeyringToken(ctx, addr, kt))
+
+	sbs := []*storagebuckets.StorageBucket{
+		storageBucket("1"),
+		storageBucket("2"),
+		storageBucket("3"),
+	}
+	var want []*StorageBucket
+	for _, sb := range sbs {
+		si, err := json.Marshal(sb)
+		require.NoError(t, err)
+		want = append(want, &StorageBucket{
+			FkUserId: u.Id,
+			Id:       sb.Id,
+			Name:     sb.Name,
+			ScopeId:  sb.ScopeId,
+			Item:     string(si),
+		})
+	}
+	cases := []struct {
+		name           string
+		u              *user
+		storageBuckets []*storagebuckets.StorageBucket
+		want           []*StorageBucket
+		errorContains  string
+	}{
+		{
+			name: "Success",
+			u: &user{
+				Id:      at.UserId,
+				Address: addr,
+			},
+			storageBuckets: sbs,
+			want:           want,
+		},
+		// this test case must run after the above test case so as to exercise
+		// the update logic of refresh.
+		{
+			name: "repeated storage bucket with different values",
+			u: &user{
+				Id:      at.UserId,
+				Address: addr,
+			},
+			storageBuckets: append(sbs, &storagebuckets.StorageBucket{
+				Id:   sbs[0].Id,
+				Name: "a different name",
+			}),
+			want: append(want[1:],
+				&StorageBucket{
+					FkUserId: want[0].FkUserId,
+					Id:       want[0].Id,
+					Name:     "a different name",
+					Item:     `{"id":"sb_1","name":"a different name","created_time":"0001-01-01T00:00:00Z","updated_time":"0001-01-01T00:00:00Z"}`,
+				}),
+		},
+		{
+			name:           "nil user",
+			u:              nil,
+			storageBuckets: sbs,
+			errorContains:  "user is nil",
+		},
+		{
+			name: "missing user Id",
+			u: &user{
+				Address: addr,
+			},
+			storageBuckets: sbs,
+			errorContains:  "user id is missing",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := r.refreshStorageBuckets(ctx, tc.u, map[AuthToken]string{{Id: "id"}: "something"},
+				WithStorageBucketRetrievalFunc(testStaticResourceRetrievalFunc(t, [][]*storagebuckets.StorageBucket{tc.storageBuckets}, [][]string{nil})))
+			if tc.errorContains == "" {
+				assert.NoError(t, err)
+				rw := db.New(s)
+				var got []*StorageBucket
+				require.NoError(t, rw.SearchWhere(ctx, &got, "true", nil))
+				assert.ElementsMatch(t, got, tc.want)
+
+				t.Cleanup(func() {
+					refTok := &refreshToken{
+						UserId:       tc.u.Id,
+						ResourceType: storageBucketResourceType,
+					}
+					_, err := r.rw.Delete(ctx, refTok)
+					require.NoError(t, err)
+				})
+			} else {
+				assert.ErrorContains(t, err, tc.errorContains)
+			}
+		})
+	}
+}
+
+func TestRepository_ListStorageBuckets(t *testing.T) {
+	ctx := context.Background()
+	s, err := cachedb.Open(ctx)
+	require.NoError(t, err)
+
+	addr := "address"
+	u1 := &user{
+		Id:      "u1",
+		Address: addr,
+	}
+	at1 := &authtokens.AuthToken{
+		Id:     "at_1",
+		Token:  "at_1_token",
+		UserId: u1.Id,
+	}
+	kt1 := KeyringToken{KeyringType: "k1", TokenName: "t1", AuthTokenId: at1.Id}
+
+	u2 := &user{
+		Id:      "u2",
+		Address: addr,
+	}
+	at2 := &authtokens.AuthToken{
+		Id:     "at_2",
+		Token:  "at_2_token",
+		UserId: u2.Id,
+	}
+	kt2 := KeyringToken{KeyringType: "k2", TokenName: "t2", AuthTokenId: at2.Id}
+	atMap := map[ringToken]*authtokens.AuthToken{
+		{"k1", "t1"}: at1,
+		{"k2", "t2"}: at2,
+	}
+	r, err := NewRepository(ctx, s, &sync.Map{}, mapBasedAuthTokenKeyringLookup(atMap), sliceBasedAuthTokenBoundaryReader(maps.Values(atMap)))
+	require.NoError(t, err)
+	require.NoError(t, r.AddKeyringToken(ctx, addr, kt1))
+	require.NoError(t, r.AddKeyringToken(ctx, addr, kt2))
+
+	t.Run("token is missing", func(t *testing.T) {
+		l, err := r.ListStorageBuckets(ctx, "")
+		assert.Nil(t, l)
+		assert.ErrorContains(t, err, "auth token id is missing")
+	})
+
+	sbs := []*storagebuckets.StorageBucket{
+		storageBucket("1"),
+		storageBucket("2"),
+		storageBucket("3"),
+	}
+	require.NoError(t, r.refreshStorageBuckets(ctx, u1, map[AuthToken]string{{Id: "id"}: "something"},
+		WithStorageBucketRetrievalFunc(testStaticResourceRetrievalFunc(t, [][]*storagebuckets.StorageBucket{sbs}, [][]string{nil}))))
+
+	t.Run("wrong user gets no storage buckets", func(t *testing.T) {
+		l, err := r.ListStorageBuckets(ctx, kt2.AuthTokenId)
+		assert.NoError(t, err)
+		assert.Empty(t, l)
+	})
+	t.Run("correct token gets storage buckets", func(t *testing.T) {
+		l, err := r.ListStorageBuckets(ctx, kt1.AuthTokenId)
+		assert.NoError(t, err)
+		assert.Len(t, l, len(sbs))
+		assert.ElementsMatch(t, l, sbs)
+	})
+}
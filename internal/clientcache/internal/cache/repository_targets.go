@@ -9,12 +9,18 @@ import (
 	"encoding/json"
 	stderrors "errors"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/hashicorp/boundary/api"
+	"github.com/hashicorp/boundary/api/authtokens"
 	"github.com/hashicorp/boundary/api/targets"
+	"github.com/hashicorp/boundary/globals"
 	"github.com/hashicorp/boundary/internal/db"
 	"github.com/hashicorp/boundary/internal/errors"
 	"github.com/hashicorp/boundary/internal/event"
+	"github.com/hashicorp/boundary/internal/types/resource"
 	"github.com/hashicorp/boundary/internal/util"
 	"github.com/hashicorp/mql"
 )
@@ -46,31 +52,60 @@ func defaultTargetFunc(ctx context.Context, addr, authTok string, refreshTok Ref
 	return l.Items, l.RemovedIds, RefreshTokenValue(l.ListToken), nil
 }
 
+// ErrSkippedInvalidIds is returned, wrapped, when a refresh skipped some of
+// the retrieved resources because their ids aren't valid. The refresh of the
+// remaining resources has still been committed, so callers can tell it apart
+// from a failed refresh with errors.Is.
+var ErrSkippedInvalidIds = stderrors.New("skipped resources with invalid ids")
+
+// RefreshCounts are the number of cached resources a refresh added, updated
+// and removed. A refresh which replaces all of a user's cached resources
+// counts each of them as removed and each resource it caches as added.
+type RefreshCounts struct {
+	Added   int
+	Updated int
+	Removed int
+}
+
 // refreshTargets uses attempts to refresh the targets for the provided user
 // using the provided tokens. If available, it uses the refresh tokens in
-// storage to retrieve and apply only the delta.
-func (r *Repository) refreshTargets(ctx context.Context, u *user, tokens map[AuthToken]string, opt ...Option) error {
+// storage to retrieve and apply only the delta. The number of cached targets
+// the refresh added, updated and removed is returned. Supported options are:
+//   - WithTargetRetrievalFunc
+//   - WithIncrementalRefresh
+func (r *Repository) refreshTargets(ctx context.Context, u *user, tokens map[AuthToken]string, opt ...Option) (_ RefreshCounts, err error) {
 	const op = "cache.(Repository).refreshTargets"
+	defer r.observeQuery(resource.Target, "refresh", time.Now(), &err)
+	if err := r.checkOpen(ctx, op); err != nil {
+		return RefreshCounts{}, err
+	}
 	switch {
 	case util.IsNil(u):
-		return errors.New(ctx, errors.InvalidParameter, op, "user is nil")
+		return RefreshCounts{}, errors.New(ctx, errors.InvalidParameter, op, "user is nil")
 	case u.Id == "":
-		return errors.New(ctx, errors.InvalidParameter, op, "user id is missing")
+		return RefreshCounts{}, errors.New(ctx, errors.InvalidParameter, op, "user id is missing")
 	}
 	const resourceType = targetResourceType
 
+	// Refreshes of the same user must not interleave, otherwise they could
+	// each apply their changes on top of a snapshot the other is replacing.
+	unlock := r.lockUser(u.Id)
+	defer unlock()
+
 	opts, err := getOpts(opt...)
 	if err != nil {
-		return errors.Wrap(ctx, err, op)
+		return RefreshCounts{}, errors.Wrap(ctx, err, op)
 	}
 	if opts.withTargetRetrievalFunc == nil {
 		opts.withTargetRetrievalFunc = defaultTargetFunc
 	}
 
 	var oldRefreshTokenVal RefreshTokenValue
-	oldRefreshToken, err := r.lookupRefreshToken(ctx, u, resourceType)
+	lookupCtx, cancel := r.withQueryTimeout(ctx)
+	oldRefreshToken, err := r.lookupRefreshToken(lookupCtx, u, resourceType)
+	cancel()
 	if err != nil {
-		return errors.Wrap(ctx, err, op)
+		return RefreshCounts{}, errors.Wrap(ctx, err, op)
 	}
 	if oldRefreshToken != nil {
 		oldRefreshTokenVal = oldRefreshToken.RefreshToken
@@ -87,8 +122,11 @@ func (r *Repository) refreshTargets(ctx context.Context, u *user, tokens map[Aut
 		resp, removedIds, newRefreshToken, err = opts.withTargetRetrievalFunc(ctx, u.Address, t, oldRefreshTokenVal)
 		if api.ErrInvalidListToken.Is(err) {
 			event.WriteSysEvent(ctx, op, "old list token is no longer valid, starting new initial fetch", "user_id", u.Id)
-			if err := r.deleteRefreshToken(ctx, u, resourceType); err != nil {
-				return errors.Wrap(ctx, err, op)
+			deleteCtx, cancel := r.withQueryTimeout(ctx)
+			err := r.deleteRefreshToken(deleteCtx, u, resourceType)
+			cancel()
+			if err != nil {
+				return RefreshCounts{}, errors.Wrap(ctx, err, op)
 			}
 			// try again without the refresh token
 			oldRefreshToken = nil
@@ -107,27 +145,57 @@ func (r *Repository) refreshTargets(ctx context.Context, u *user, tokens map[Aut
 	}
 	if retErr != nil {
 		if saveErr := r.saveError(r.serverCtx, u, resourceType, retErr); saveErr != nil {
-			return stderrors.Join(err, errors.Wrap(ctx, saveErr, op))
+			return RefreshCounts{}, stderrors.Join(err, errors.Wrap(ctx, saveErr, op))
 		}
 	}
 	if !gotResponse {
-		return retErr
+		return RefreshCounts{}, retErr
 	}
 
+	// Targets without a valid id are never cached so corrupt data can't
+	// poison the cache; the rest of the refresh still goes ahead and
+	// ErrSkippedInvalidIds is returned once it has been committed.
+	resp, skippedIds, err := partitionTargetsById(ctx, resp, resource.Target.Prefixes())
+	if err != nil {
+		return RefreshCounts{}, errors.Wrap(ctx, err, op)
+	}
+
+	// Only the writes to the cache are bounded by the query timeout, not the
+	// requests made to boundary above.
+	ctx, cancel = r.withQueryTimeout(ctx)
+	defer cancel()
+
 	var numDeleted int
-	_, err = r.rw.DoTx(ctx, db.StdRetryCnt, db.ExpBackoff{}, func(_ db.Reader, w db.Writer) error {
+	var counts RefreshCounts
+	// An incremental refresh replaces the cached targets with the full set
+	// returned by boundary by only writing the targets which changed.
+	incremental := opts.withIncrementalRefresh && oldRefreshToken == nil && !unsupportedCacheRequest
+	_, err = r.rw.DoTx(ctx, db.StdRetryCnt, db.ExpBackoff{}, func(reader db.Reader, w db.Writer) error {
 		var err error
+		numDeleted, counts = 0, RefreshCounts{}
+		now := r.clock()
 		switch {
+		case incremental:
+			cachedItems, err := cachedTargetItems(ctx, reader, u)
+			if err != nil {
+				return err
+			}
+			if counts, err = syncTargets(ctx, w, u, resp, cachedItems, now); err != nil {
+				return err
+			}
+			numDeleted = counts.Removed
 		case oldRefreshToken == nil || unsupportedCacheRequest:
 			if numDeleted, err = w.Exec(ctx, "delete from target where fk_user_id = @fk_user_id",
 				[]any{sql.Named("fk_user_id", u.Id)}); err != nil {
 				return err
 			}
+			counts.Removed = numDeleted
 		case len(removedIds) > 0:
-			if numDeleted, err = w.Exec(ctx, "delete from target where id in @ids",
-				[]any{sql.Named("ids", removedIds)}); err != nil {
+			if numDeleted, err = w.Exec(ctx, "delete from target where fk_user_id = @fk_user_id and id in @ids",
+				[]any{sql.Named("fk_user_id", u.Id), sql.Named("ids", removedIds)}); err != nil {
 				return err
 			}
+			counts.Removed = numDeleted
 		}
 		switch {
 		case unsupportedCacheRequest:
@@ -135,8 +203,14 @@ func (r *Repository) refreshTargets(ctx context.Context, u *user, tokens map[Aut
 				return err
 			}
 		case newRefreshToken != "":
-			if err := upsertTargets(ctx, w, u, resp); err != nil {
-				return err
+			if !incremental {
+				written, err := upsertTargets(ctx, w, u, resp, now)
+				if err != nil {
+					return err
+				}
+				counts.Added += written.Added
+				counts.Updated += written.Updated
+				counts.Removed += written.Removed
 			}
 			if err := upsertRefreshToken(ctx, w, u, resourceType, newRefreshToken); err != nil {
 				return err
@@ -144,16 +218,176 @@ func (r *Repository) refreshTargets(ctx context.Context, u *user, tokens map[Aut
 		default:
 			// controller supports caching, but doesn't have any resources
 		}
-		return nil
+		// The refresh time is also the last refresh time of every target
+		// cached for the user, so unchanged targets aren't written.
+		return upsertRefreshTime(ctx, w, u, resourceType, now)
 	})
 	if err != nil {
-		return errors.Wrap(ctx, err, op)
+		return RefreshCounts{}, errors.Wrap(ctx, err, op)
 	}
+	r.emitter.TargetsRefreshed(ctx, &TargetsRefreshedEvent{UserId: u.Id, Added: counts.Added, Updated: counts.Updated, Removed: counts.Removed})
 	if unsupportedCacheRequest {
-		return ErrRefreshNotSupported
+		return counts, ErrRefreshNotSupported
 	}
 	event.WriteSysEvent(ctx, op, "targets updated", "deleted", numDeleted, "upserted", len(resp), "user_id", u.Id)
-	return nil
+	if err := r.skippedTargetsError(ctx, u, skippedIds); err != nil {
+		return counts, errors.Wrap(ctx, err, op)
+	}
+	return counts, nil
+}
+
+// skippedTargetsError returns an error wrapping ErrSkippedInvalidIds if any
+// target ids were skipped, after saving it as the user's last target error so
+// it is reported in the cache's status. Nil is returned if no ids were skipped.
+func (r *Repository) skippedTargetsError(ctx context.Context, u *user, skippedIds []string) error {
+	const op = "cache.(Repository).skippedTargetsError"
+	if len(skippedIds) == 0 {
+		return nil
+	}
+	err := errors.Wrap(ctx, ErrSkippedInvalidIds, op, errors.WithMsg("target ids: %s", strings.Join(skippedIds, ", ")), errors.WithoutEvent())
+	if saveErr := r.saveError(r.serverCtx, u, targetResourceType, err); saveErr != nil {
+		return stderrors.Join(err, errors.Wrap(ctx, saveErr, op))
+	}
+	return err
+}
+
+// partitionTargetsById returns the provided targets whose id starts with one
+// of the provided public id prefixes and the ids of the ones which don't. Nil
+// targets are dropped. The prefixes are normally resource.Target.Prefixes(),
+// which are registered by the globals package; an error is returned if there
+// are none, rather than treating every target as invalid.
+func partitionTargetsById(ctx context.Context, in []*targets.Target, prefixes []string) ([]*targets.Target, []string, error) {
+	const op = "cache.partitionTargetsById"
+	if len(prefixes) == 0 {
+		return nil, nil, errors.New(ctx, errors.Internal, op, "no target id prefixes are registered")
+	}
+	valid := make([]*targets.Target, 0, len(in))
+	var invalidIds []string
+	for _, t := range in {
+		if t == nil {
+			continue
+		}
+		if !slices.ContainsFunc(prefixes, func(p string) bool {
+			return strings.HasPrefix(t.Id, p+"_")
+		}) {
+			invalidIds = append(invalidIds, t.Id)
+			continue
+		}
+		valid = append(valid, t)
+	}
+	return valid, invalidIds, nil
+}
+
+// cachedTargetItems returns the items of the targets cached for the provided
+// user, keyed by the target id.
+func cachedTargetItems(ctx context.Context, r db.Reader, u *user) (map[string]string, error) {
+	const op = "cache.cachedTargetItems"
+	switch {
+	case util.IsNil(r):
+		return nil, errors.New(ctx, errors.InvalidParameter, op, "reader is nil")
+	case util.IsNil(u):
+		return nil, errors.New(ctx, errors.InvalidParameter, op, "user is nil")
+	}
+	rows, err := r.Query(ctx, "select id, coalesce(item, '') from target where fk_user_id = @fk_user_id",
+		[]any{sql.Named("fk_user_id", u.Id)})
+	if err != nil {
+		return nil, errors.Wrap(ctx, err, op)
+	}
+	defer rows.Close()
+	ret := make(map[string]string)
+	for rows.Next() {
+		var id, item string
+		if err := rows.Scan(&id, &item); err != nil {
+			return nil, errors.Wrap(ctx, err, op)
+		}
+		ret[id] = item
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(ctx, err, op)
+	}
+	return ret, nil
+}
+
+// RefreshAllTargets refreshes the cached targets of every user in the cache.
+// The tokens available for each user are first validated using the
+// repository's boundary token reader and only the valid ones are used to
+// retrieve the user's targets. Users for which no valid token can be found are
+// skipped. A failure for one user does not stop the refresh of the remaining
+// users; instead all encountered errors are joined and returned, including
+// any wrapping ErrSkippedInvalidIds for refreshes which were still committed.
+// Supported options are:
+//   - WithTargetRetrievalFunc
+//   - WithIncrementalRefresh
+func (r *Repository) RefreshAllTargets(ctx context.Context, opt ...Option) error {
+	const op = "cache.(Repository).RefreshAllTargets"
+	if err := r.checkOpen(ctx, op); err != nil {
+		return err
+	}
+	us, err := r.listUsers(ctx)
+	if err != nil {
+		return errors.Wrap(ctx, err, op)
+	}
+
+	var retErr error
+	for _, u := range us {
+		tokens, err := r.validUserTokens(ctx, u)
+		if err != nil {
+			retErr = stderrors.Join(retErr, errors.Wrap(ctx, err, op, errors.WithMsg("for user id %s", u.Id)))
+		}
+		if len(tokens) == 0 {
+			event.WriteSysEvent(ctx, op, "skipping targets refresh for user without a valid auth token", "user_id", u.Id)
+			continue
+		}
+		if _, err := r.refreshTargets(ctx, u, tokens, opt...); err != nil {
+			retErr = stderrors.Join(retErr, errors.Wrap(ctx, err, op, errors.WithMsg("for user id %s", u.Id)))
+		}
+	}
+	return retErr
+}
+
+// validUserTokens returns the tokens for the provided user which are still
+// present in the keyring or in memory and which can be read from boundary. Any
+// errors encountered while reading a token from boundary are joined and
+// returned along with the tokens which were found to be valid.
+func (r *Repository) validUserTokens(ctx context.Context, u *user) (map[AuthToken]string, error) {
+	const op = "cache.(Repository).validUserTokens"
+	switch {
+	case util.IsNil(u):
+		return nil, errors.New(ctx, errors.InvalidParameter, op, "user is nil")
+	}
+	ats, err := r.listTokens(ctx, u)
+	if err != nil {
+		return nil, errors.Wrap(ctx, err, op)
+	}
+
+	ret := make(map[AuthToken]string)
+	var retErr error
+	for _, at := range ats {
+		var candidates []string
+		kts, err := r.listKeyringTokens(ctx, at)
+		if err != nil {
+			return nil, errors.Wrap(ctx, err, op)
+		}
+		for _, kt := range kts {
+			if kat := r.tokenKeyringFn(kt.KeyringType, kt.TokenName); kat != nil && kat.Id == at.Id {
+				candidates = append(candidates, kat.Token)
+			}
+		}
+		if v, ok := r.idToKeyringlessAuthToken.Load(at.Id); ok {
+			if kat, ok := v.(*authtokens.AuthToken); ok {
+				candidates = append(candidates, kat.Token)
+			}
+		}
+		for _, tok := range candidates {
+			if _, err := r.tokenReadFromBoundaryFn(ctx, u.Address, tok); err != nil {
+				retErr = stderrors.Join(retErr, errors.Wrap(ctx, err, op, errors.WithMsg("for auth token %q", at.Id)))
+				continue
+			}
+			ret[*at] = tok
+			break
+		}
+	}
+	return ret, retErr
 }
 
 // checkCachingTargets fetches all targets for the provided user. If the
@@ -164,6 +398,9 @@ func (r *Repository) refreshTargets(ctx context.Context, u *user, tokens map[Aut
 // is not marked as unknown.
 func (r *Repository) checkCachingTargets(ctx context.Context, u *user, tokens map[AuthToken]string, opt ...Option) error {
 	const op = "cache.(Repository).checkCachingTargets"
+	if err := r.checkOpen(ctx, op); err != nil {
+		return err
+	}
 	switch {
 	case util.IsNil(u):
 		return errors.New(ctx, errors.InvalidParameter, op, "user is nil")
@@ -225,12 +462,16 @@ func (r *Repository) checkCachingTargets(ctx context.Context, u *user, tokens ma
 				[]any{sql.Named("fk_user_id", u.Id)}); err != nil {
 				return err
 			}
-			if err := upsertTargets(ctx, w, u, resp); err != nil {
+			now := r.clock()
+			if _, err := upsertTargets(ctx, w, u, resp, now); err != nil {
 				return err
 			}
 			if err := upsertRefreshToken(ctx, w, u, resourceType, newRefreshToken); err != nil {
 				return err
 			}
+			if err := upsertRefreshTime(ctx, w, u, resourceType, now); err != nil {
+				return err
+			}
 		default:
 			// We know the controller supports caching, but doesn't have a
 			// refresh token so clear out any refresh token we have for this resource.
@@ -251,58 +492,431 @@ func (r *Repository) checkCachingTargets(ctx context.Context, u *user, tokens ma
 }
 
 // upsertTargets upserts the provided targets to be stored for the provided user.
-func upsertTargets(ctx context.Context, w db.Writer, u *user, in []*targets.Target) error {
+// Targets which weren't cached before are recorded as first seen at the
+// provided time. Cached targets which are unchanged aren't written. The number
+// of targets added, updated and removed is returned.
+func upsertTargets(ctx context.Context, w db.Writer, u *user, in []*targets.Target, firstSeen time.Time) (RefreshCounts, error) {
 	const op = "cache.upsertTargets"
 	switch {
 	case util.IsNil(w):
-		return errors.New(ctx, errors.InvalidParameter, op, "writer is nil")
+		return RefreshCounts{}, errors.New(ctx, errors.InvalidParameter, op, "writer is nil")
 	case !w.IsTx(ctx):
-		return errors.New(ctx, errors.InvalidParameter, op, "writer isn't in a transaction")
+		return RefreshCounts{}, errors.New(ctx, errors.InvalidParameter, op, "writer isn't in a transaction")
 	case util.IsNil(u):
-		return errors.New(ctx, errors.InvalidParameter, op, "user is nil")
+		return RefreshCounts{}, errors.New(ctx, errors.InvalidParameter, op, "user is nil")
 	}
 
+	var ret RefreshCounts
 	for _, t := range in {
-		item, err := json.Marshal(t)
+		// Stop if the context was cancelled so the transaction this is a part
+		// of is rolled back, leaving the previously cached targets untouched.
+		if err := ctx.Err(); err != nil {
+			return RefreshCounts{}, errors.Wrap(ctx, err, op)
+		}
+		newTarget, err := newCachedTarget(u, t, firstSeen)
 		if err != nil {
-			return errors.Wrap(ctx, err, op)
-		}
-		newTarget := &Target{
-			FkUserId:    u.Id,
-			Id:          t.Id,
-			Name:        t.Name,
-			Description: t.Description,
-			Address:     t.Address,
-			ScopeId:     t.ScopeId,
-			Type:        t.Type,
-			Item:        string(item),
-		}
-		onConflict := db.OnConflict{
+			return RefreshCounts{}, errors.Wrap(ctx, err, op)
+		}
+		if newTarget == nil {
+			// Remove any previously cached version of the target in case it
+			// lost its address since the last refresh.
+			n, err := w.Exec(ctx, "delete from target where fk_user_id = @fk_user_id and id = @id",
+				[]any{sql.Named("fk_user_id", u.Id), sql.Named("id", t.Id)})
+			if err != nil {
+				return RefreshCounts{}, errors.Wrap(ctx, err, op)
+			}
+			ret.Removed += n
+			continue
+		}
+		var inserted int64
+		if err := w.Create(ctx, newTarget, db.WithOnConflict(&db.OnConflict{
 			Target: db.Columns{"fk_user_id", "id"},
-			Action: db.SetColumns([]string{"name", "description", "address", "scope_id", "type", "item"}),
+			Action: db.DoNothing(true),
+		}), db.WithReturnRowsAffected(&inserted)); err != nil {
+			return RefreshCounts{}, errors.Wrap(ctx, err, op)
 		}
-		if err := w.Create(ctx, newTarget, db.WithOnConflict(&onConflict)); err != nil {
-			return errors.Wrap(ctx, err, op)
+		if inserted > 0 {
+			ret.Added++
+			continue
+		}
+		var updated int64
+		if err := upsertTarget(ctx, w, newTarget, db.WithWhere("target.item is not excluded.item"), db.WithReturnRowsAffected(&updated)); err != nil {
+			return RefreshCounts{}, errors.Wrap(ctx, err, op)
+		}
+		if updated > 0 {
+			ret.Updated++
 		}
 	}
-	return nil
+	return ret, nil
 }
 
-func (r *Repository) ListTargets(ctx context.Context, authTokenId string) ([]*targets.Target, error) {
+// StaleUsers returns the ids, sorted in ascending order, of the users which
+// have cached targets that have not been refreshed from boundary within the
+// provided max age. Users whose cached targets have never been refreshed, such
+// as ones which were imported, are also returned.
+func (r *Repository) StaleUsers(ctx context.Context, maxAge time.Duration) ([]string, error) {
+	const op = "cache.(Repository).StaleUsers"
+	if err := r.checkOpen(ctx, op); err != nil {
+		return nil, err
+	}
+	switch {
+	case maxAge < 0:
+		return nil, errors.New(ctx, errors.InvalidParameter, op, "max age is negative")
+	}
+	var users []*user
+	if err := r.rw.SearchWhere(ctx, &users, "id in (select fk_user_id from target) and id not in (select user_id from refresh_time where resource_type = ? and refresh_time >= ?)",
+		[]any{targetResourceType, r.clock().Add(-maxAge)}, db.WithLimit(-1), db.WithOrder("id asc")); err != nil {
+		return nil, errors.Wrap(ctx, err, op)
+	}
+	ret := make([]string, 0, len(users))
+	for _, u := range users {
+		ret = append(ret, u.Id)
+	}
+	return ret, nil
+}
+
+// syncTargets makes the targets cached for the provided user match the provided
+// targets, which must be the full set of targets the user can list. Only the
+// targets which are new or changed compared to the provided cached items are
+// written, so unchanged targets keep their first seen time. Targets which are
+// no longer present are removed. The number of targets added, updated and
+// removed is returned.
+func syncTargets(ctx context.Context, w db.Writer, u *user, in []*targets.Target, cachedItems map[string]string, firstSeen time.Time) (RefreshCounts, error) {
+	const op = "cache.syncTargets"
+	switch {
+	case util.IsNil(w):
+		return RefreshCounts{}, errors.New(ctx, errors.InvalidParameter, op, "writer is nil")
+	case !w.IsTx(ctx):
+		return RefreshCounts{}, errors.New(ctx, errors.InvalidParameter, op, "writer isn't in a transaction")
+	case util.IsNil(u):
+		return RefreshCounts{}, errors.New(ctx, errors.InvalidParameter, op, "user is nil")
+	}
+
+	var ret RefreshCounts
+	kept := make(map[string]bool, len(in))
+	for _, t := range in {
+		// Stop if the context was cancelled so the transaction this is a part
+		// of is rolled back, leaving the previously cached targets untouched.
+		if err := ctx.Err(); err != nil {
+			return RefreshCounts{}, errors.Wrap(ctx, err, op)
+		}
+		newTarget, err := newCachedTarget(u, t, firstSeen)
+		if err != nil {
+			return RefreshCounts{}, errors.Wrap(ctx, err, op)
+		}
+		if newTarget == nil || kept[newTarget.Id] {
+			continue
+		}
+		kept[newTarget.Id] = true
+		item, ok := cachedItems[newTarget.Id]
+		switch {
+		case !ok:
+			ret.Added++
+		case item != newTarget.Item:
+			ret.Updated++
+		default:
+			continue
+		}
+		if err := upsertTarget(ctx, w, newTarget); err != nil {
+			return RefreshCounts{}, errors.Wrap(ctx, err, op)
+		}
+	}
+
+	var removedIds []string
+	for id := range cachedItems {
+		if !kept[id] {
+			removedIds = append(removedIds, id)
+		}
+	}
+	if len(removedIds) == 0 {
+		return ret, nil
+	}
+	numDeleted, err := w.Exec(ctx, "delete from target where fk_user_id = @fk_user_id and id in @ids",
+		[]any{sql.Named("fk_user_id", u.Id), sql.Named("ids", removedIds)})
+	if err != nil {
+		return RefreshCounts{}, errors.Wrap(ctx, err, op)
+	}
+	ret.Removed = numDeleted
+	return ret, nil
+}
+
+// newCachedTarget returns the Target to cache for the provided user and
+// boundary target. A target without an address or host sources can't be
+// connected to, so nil is returned for it and it isn't cached.
+func newCachedTarget(u *user, t *targets.Target, firstSeen time.Time) (*Target, error) {
+	address := normalizeTargetAddress(t.Address)
+	if address == "" && len(t.HostSourceIds) == 0 && len(t.HostSources) == 0 {
+		return nil, nil
+	}
+	item, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	return &Target{
+		FkUserId:          u.Id,
+		Id:                t.Id,
+		Name:              t.Name,
+		Description:       t.Description,
+		Address:           address,
+		ScopeId:           t.ScopeId,
+		Type:              t.Type,
+		SessionMaxSeconds: t.SessionMaxSeconds,
+		Item:              string(item),
+		FirstSeenTime:     firstSeen,
+	}, nil
+}
+
+// upsertTarget upserts the provided target. The first seen time of a target
+// which is already cached is left unchanged.
+func upsertTarget(ctx context.Context, w db.Writer, t *Target, opt ...db.Option) error {
+	onConflict := db.OnConflict{
+		Target: db.Columns{"fk_user_id", "id"},
+		Action: db.SetColumns([]string{"name", "description", "address", "scope_id", "type", "session_max_seconds", "item"}),
+	}
+	return w.Create(ctx, t, append([]db.Option{db.WithOnConflict(&onConflict)}, opt...)...)
+}
+
+// normalizeTargetAddress returns the provided target address with any
+// surrounding whitespace removed and in lower case, so that cached addresses
+// can be consistently searched and sorted. The original address is kept as
+// part of the cached item.
+func normalizeTargetAddress(a string) string {
+	return strings.ToLower(strings.TrimSpace(a))
+}
+
+// ListTargets returns all the cached targets for the user associated with the
+// provided auth token id. Supported options are:
+//   - WithSort
+//   - WithMaxAge
+//   - WithLimit
+//   - WithScopeId
+//   - WithTargetType
+//   - WithFields
+func (r *Repository) ListTargets(ctx context.Context, authTokenId string, opt ...Option) (_ []*targets.Target, err error) {
 	const op = "cache.(Repository).ListTargets"
+	defer r.observeQuery(resource.Target, "list", time.Now(), &err)
+	if err := r.checkOpen(ctx, op); err != nil {
+		return nil, err
+	}
 	switch {
 	case authTokenId == "":
 		return nil, errors.New(ctx, errors.InvalidParameter, op, "auth token id is missing")
 	}
-	ret, err := r.searchTargets(ctx, "true", nil, withAuthTokenId(authTokenId))
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
+	ret, err := r.searchTargets(ctx, "true", nil, append(opt, withAuthTokenId(authTokenId))...)
 	if err != nil {
 		return nil, errors.Wrap(ctx, err, op)
 	}
+	if err := r.checkStaleTargets(ctx, authTokenId); err != nil {
+		return nil, errors.Wrap(ctx, err, op)
+	}
 	return ret, nil
 }
 
-func (r *Repository) QueryTargets(ctx context.Context, authTokenId, query string) ([]*targets.Target, error) {
+// GetTarget returns the cached target with the provided id for the user
+// associated with the provided auth token id. A NotFound error is returned if
+// the target isn't cached for that user.
+func (r *Repository) GetTarget(ctx context.Context, authTokenId, targetId string) (*targets.Target, error) {
+	const op = "cache.(Repository).GetTarget"
+	if err := r.checkOpen(ctx, op); err != nil {
+		return nil, err
+	}
+	switch {
+	case authTokenId == "":
+		return nil, errors.New(ctx, errors.InvalidParameter, op, "auth token id is missing")
+	case targetId == "":
+		return nil, errors.New(ctx, errors.InvalidParameter, op, "target id is missing")
+	}
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
+	ret, err := r.searchTargets(ctx, "id = ?", []any{targetId}, withAuthTokenId(authTokenId))
+	if err != nil {
+		return nil, errors.Wrap(ctx, err, op)
+	}
+	if len(ret) == 0 {
+		return nil, errors.New(ctx, errors.NotFound, op, fmt.Sprintf("target %q not found", targetId), errors.WithoutEvent())
+	}
+	return ret[0], nil
+}
+
+// DistinctTargetAddresses returns the distinct, non-empty addresses of the
+// targets cached for the user associated with the provided auth token id,
+// sorted in ascending order.
+func (r *Repository) DistinctTargetAddresses(ctx context.Context, authTokenId string) ([]string, error) {
+	const op = "cache.(Repository).DistinctTargetAddresses"
+	if err := r.checkOpen(ctx, op); err != nil {
+		return nil, err
+	}
+	switch {
+	case authTokenId == "":
+		return nil, errors.New(ctx, errors.InvalidParameter, op, "auth token id is missing")
+	}
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+select distinct address
+  from target
+ where fk_user_id in (select user_id from auth_token where id = @auth_token_id)
+   and address is not null
+   and address != ''
+ order by address`
+	rows, err := r.rw.Query(ctx, query, []any{sql.Named("auth_token_id", authTokenId)})
+	if err != nil {
+		return nil, errors.Wrap(ctx, err, op)
+	}
+	defer rows.Close()
+	ret := []string{}
+	for rows.Next() {
+		var address string
+		if err := rows.Scan(&address); err != nil {
+			return nil, errors.Wrap(ctx, err, op)
+		}
+		ret = append(ret, address)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(ctx, err, op)
+	}
+	return ret, nil
+}
+
+// UpsertTarget caches the provided target for the provided user, replacing
+// any previously cached version of it while leaving the user's other cached
+// targets untouched. A target without an address or host sources is removed
+// instead, the same as in a full refresh.
+func (r *Repository) UpsertTarget(ctx context.Context, u *user, t *targets.Target) error {
+	const op = "cache.(Repository).UpsertTarget"
+	if err := r.checkOpen(ctx, op); err != nil {
+		return err
+	}
+	switch {
+	case util.IsNil(u):
+		return errors.New(ctx, errors.InvalidParameter, op, "user is nil")
+	case u.Id == "":
+		return errors.New(ctx, errors.InvalidParameter, op, "user id is missing")
+	case util.IsNil(t):
+		return errors.New(ctx, errors.InvalidParameter, op, "target is nil")
+	case t.Id == "":
+		return errors.New(ctx, errors.InvalidParameter, op, "target id is missing")
+	}
+
+	unlock := r.lockUser(u.Id)
+	defer unlock()
+
+	_, err := r.rw.DoTx(ctx, db.StdRetryCnt, db.ExpBackoff{}, func(_ db.Reader, w db.Writer) error {
+		_, err := upsertTargets(ctx, w, u, []*targets.Target{t}, r.clock())
+		return err
+	})
+	if err != nil {
+		return errors.Wrap(ctx, err, op)
+	}
+	return nil
+}
+
+// RemoveTarget removes the target with the provided id from the targets
+// cached for the provided user. Removing a target which isn't cached is not an
+// error.
+func (r *Repository) RemoveTarget(ctx context.Context, u *user, targetId string) error {
+	const op = "cache.(Repository).RemoveTarget"
+	if err := r.checkOpen(ctx, op); err != nil {
+		return err
+	}
+	switch {
+	case util.IsNil(u):
+		return errors.New(ctx, errors.InvalidParameter, op, "user is nil")
+	case u.Id == "":
+		return errors.New(ctx, errors.InvalidParameter, op, "user id is missing")
+	case targetId == "":
+		return errors.New(ctx, errors.InvalidParameter, op, "target id is missing")
+	}
+
+	unlock := r.lockUser(u.Id)
+	defer unlock()
+
+	if _, err := r.rw.Exec(ctx, "delete from target where fk_user_id = @fk_user_id and id = @id",
+		[]any{sql.Named("fk_user_id", u.Id), sql.Named("id", targetId)}); err != nil {
+		return errors.Wrap(ctx, err, op)
+	}
+	return nil
+}
+
+// checkStaleTargets notifies the repository's event emitter if the targets
+// cached for the user associated with the provided auth token id were last
+// refreshed longer ago than the repository's stale threshold.
+func (r *Repository) checkStaleTargets(ctx context.Context, authTokenId string) error {
+	const op = "cache.(Repository).checkStaleTargets"
+	if r.staleThreshold == 0 {
+		return nil
+	}
+	var refreshed []*refreshTime
+	if err := r.rw.SearchWhere(ctx, &refreshed, "resource_type = ? and user_id in (select user_id from auth_token where id = ?) and user_id in (select fk_user_id from target)",
+		[]any{targetResourceType, authTokenId}, db.WithLimit(1)); err != nil {
+		return errors.Wrap(ctx, err, op)
+	}
+	if len(refreshed) == 0 {
+		return nil
+	}
+	if age := r.clock().Sub(refreshed[0].RefreshTime); age > r.staleThreshold {
+		r.emitter.StaleTargetsListed(ctx, &StaleTargetsListedEvent{
+			UserId:          refreshed[0].UserId,
+			LastRefreshTime: refreshed[0].RefreshTime,
+			Age:             age,
+		})
+	}
+	return nil
+}
+
+// ListTargetsPage returns at most pageSize targets for the user associated
+// with the provided auth token id, ordered by id. Only targets with an id
+// greater than the provided page token are returned, so an empty page token
+// returns the first page. The returned page token can be used to request the
+// next page and is empty once there are no more targets to return.
+func (r *Repository) ListTargetsPage(ctx context.Context, authTokenId string, pageSize int, pageToken string) ([]*targets.Target, string, error) {
+	const op = "cache.(Repository).ListTargetsPage"
+	if err := r.checkOpen(ctx, op); err != nil {
+		return nil, "", err
+	}
+	switch {
+	case authTokenId == "":
+		return nil, "", errors.New(ctx, errors.InvalidParameter, op, "auth token id is missing")
+	case pageSize <= 0:
+		return nil, "", errors.New(ctx, errors.InvalidParameter, op, "page size must be greater than 0")
+	}
+	condition := "true"
+	var args []any
+	if pageToken != "" {
+		condition = "id > ?"
+		args = append(args, pageToken)
+	}
+	// Request one more than the page size to find out if there is another page
+	ret, err := r.searchTargets(ctx, condition, args, withAuthTokenId(authTokenId), withOrder("id asc"), WithLimit(pageSize+1))
+	if err != nil {
+		return nil, "", errors.Wrap(ctx, err, op)
+	}
+	var nextPageToken string
+	if len(ret) > pageSize {
+		ret = ret[:pageSize]
+		nextPageToken = ret[len(ret)-1].Id
+	}
+	return ret, nextPageToken, nil
+}
+
+// QueryTargets returns the cached targets matching the provided query for the
+// user associated with the provided auth token id. The query is parsed by
+// parseTargetQuery. Supported options are:
+//   - WithSort
+//   - WithMaxAge
+//   - WithLimit
+//   - WithScopeId
+//   - WithTargetType
+//   - WithFields
+func (r *Repository) QueryTargets(ctx context.Context, authTokenId, query string, opt ...Option) (_ []*targets.Target, err error) {
 	const op = "cache.(Repository).QueryTargets"
+	defer r.observeQuery(resource.Target, "query", time.Now(), &err)
+	if err := r.checkOpen(ctx, op); err != nil {
+		return nil, err
+	}
 	switch {
 	case authTokenId == "":
 		return nil, errors.New(ctx, errors.InvalidParameter, op, "auth token id is missing")
@@ -310,17 +924,105 @@ func (r *Repository) QueryTargets(ctx context.Context, authTokenId, query string
 		return nil, errors.New(ctx, errors.InvalidParameter, op, "query is missing")
 	}
 
-	w, err := mql.Parse(query, Target{}, mql.WithIgnoredFields("FkUserId", "Item"))
+	w, err := parseTargetQuery(query)
 	if err != nil {
-		return nil, errors.Wrap(ctx, err, op, errors.WithCode(errors.InvalidParameter))
+		return nil, errors.Wrap(ctx, err, op, errors.WithCode(errors.InvalidParameter), errors.WithMsg("invalid query %q", query))
 	}
-	ret, err := r.searchTargets(ctx, w.Condition, w.Args, withAuthTokenId(authTokenId))
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
+	ret, err := r.searchTargets(ctx, w.Condition, w.Args, append(opt, withAuthTokenId(authTokenId))...)
 	if err != nil {
 		return nil, errors.Wrap(ctx, err, op)
 	}
 	return ret, nil
 }
 
+// DeleteTargetsWhere deletes the cached targets matching the provided query
+// for the user associated with the provided auth token id and returns the
+// number of targets deleted. The query uses the same grammar as QueryTargets
+// and must not be empty, so that all of a user's targets can't be deleted by
+// accident.
+func (r *Repository) DeleteTargetsWhere(ctx context.Context, authTokenId, query string) (int, error) {
+	const op = "cache.(Repository).DeleteTargetsWhere"
+	if err := r.checkOpen(ctx, op); err != nil {
+		return 0, err
+	}
+	switch {
+	case authTokenId == "":
+		return 0, errors.New(ctx, errors.InvalidParameter, op, "auth token id is missing")
+	case strings.TrimSpace(query) == "":
+		return 0, errors.New(ctx, errors.InvalidParameter, op, "query is missing")
+	}
+
+	w, err := parseTargetQuery(query)
+	if err != nil {
+		return 0, errors.Wrap(ctx, err, op, errors.WithCode(errors.InvalidParameter), errors.WithMsg("invalid query %q", query))
+	}
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
+	at, err := r.LookupToken(ctx, authTokenId)
+	if err != nil {
+		return 0, errors.Wrap(ctx, err, op)
+	}
+	if at == nil {
+		// No targets are cached for an unknown auth token.
+		return 0, nil
+	}
+
+	unlock := r.lockUser(at.UserId)
+	defer unlock()
+
+	deleteQuery := fmt.Sprintf("delete from target where (%s) and fk_user_id = ?", w.Condition)
+	numDeleted, err := r.rw.Exec(ctx, deleteQuery, append(w.Args, at.UserId))
+	if err != nil {
+		return 0, errors.Wrap(ctx, err, op)
+	}
+	return numDeleted, nil
+}
+
+// parseTargetQuery parses the provided query into a where clause for the
+// target table. Only the columns of the target table which are returned to
+// users can be used in the query.
+func parseTargetQuery(query string) (*mql.WhereClause, error) {
+	return mql.Parse(query, Target{}, mql.WithIgnoredFields("FkUserId", "Item", "FirstSeenTime"))
+}
+
+// SearchTargets returns the cached targets whose name, description or address
+// contain the provided text for the user associated with the provided auth
+// token id. The text is matched literally, so any "%" or "_" in it only match
+// themselves. Supported options are:
+//   - WithSort
+//   - WithMaxAge
+//   - WithLimit
+//   - WithScopeId
+//   - WithTargetType
+//   - WithFields
+func (r *Repository) SearchTargets(ctx context.Context, authTokenId, text string, opt ...Option) ([]*targets.Target, error) {
+	const op = "cache.(Repository).SearchTargets"
+	if err := r.checkOpen(ctx, op); err != nil {
+		return nil, err
+	}
+	switch {
+	case authTokenId == "":
+		return nil, errors.New(ctx, errors.InvalidParameter, op, "auth token id is missing")
+	case text == "":
+		return nil, errors.New(ctx, errors.InvalidParameter, op, "search text is missing")
+	}
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
+	pattern := "%" + likeEscaper.Replace(text) + "%"
+	condition := `(name like ? escape '\' or description like ? escape '\' or address like ? escape '\')`
+	ret, err := r.searchTargets(ctx, condition, []any{pattern, pattern, pattern}, append(opt, withAuthTokenId(authTokenId))...)
+	if err != nil {
+		return nil, errors.Wrap(ctx, err, op)
+	}
+	return ret, nil
+}
+
+// likeEscaper escapes the characters with a special meaning in a like pattern
+// which uses a backslash as its escape character.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
 func (r *Repository) searchTargets(ctx context.Context, condition string, searchArgs []any, opt ...Option) ([]*targets.Target, error) {
 	const op = "cache.(Repository).searchTargets"
 	switch {
@@ -333,6 +1035,24 @@ func (r *Repository) searchTargets(ctx context.Context, condition string, search
 		return nil, errors.Wrap(ctx, err, op)
 	}
 	switch {
+	case opts.withMaxAge < 0:
+		return nil, errors.New(ctx, errors.InvalidParameter, op, "max age is negative")
+	case opts.withMaxAge > 0:
+		condition = fmt.Sprintf("%s and fk_user_id in (select user_id from refresh_time where resource_type = ? and refresh_time >= ?)", condition)
+		searchArgs = append(searchArgs, targetResourceType, r.clock().Add(-opts.withMaxAge))
+	}
+	if opts.withScopeId != "" {
+		condition = fmt.Sprintf("%s and scope_id = ?", condition)
+		searchArgs = append(searchArgs, opts.withScopeId)
+	}
+	if opts.withTargetType != "" {
+		if !targetSubtypes[opts.withTargetType] {
+			return nil, errors.New(ctx, errors.InvalidParameter, op, fmt.Sprintf("unknown target type %q", opts.withTargetType))
+		}
+		condition = fmt.Sprintf("%s and type = ?", condition)
+		searchArgs = append(searchArgs, opts.withTargetType.String())
+	}
+	switch {
 	case opts.withAuthTokenId != "" && opts.withUserId != "":
 		return nil, errors.New(ctx, errors.InvalidParameter, op, "both user id and auth token id were provided")
 	case opts.withAuthTokenId == "" && opts.withUserId == "":
@@ -345,8 +1065,43 @@ func (r *Repository) searchTargets(ctx context.Context, condition string, search
 		searchArgs = append(searchArgs, opts.withUserId)
 	}
 
+	dbOpts := []db.Option{db.WithLimit(-1)}
+	if opts.withLimit > 0 {
+		dbOpts = []db.Option{db.WithLimit(opts.withLimit)}
+	}
+	if opts.withSortColumn != "" {
+		if !targetSortColumns[opts.withSortColumn] {
+			return nil, errors.New(ctx, errors.InvalidParameter, op, fmt.Sprintf("%q is not a sortable target column", opts.withSortColumn))
+		}
+		dir := opts.withSortDirection
+		switch dir {
+		case "":
+			dir = AscendingSortDirection
+		case AscendingSortDirection, DescendingSortDirection:
+		default:
+			return nil, errors.New(ctx, errors.InvalidParameter, op, fmt.Sprintf("unknown sort direction %q", dir))
+		}
+		opts.withOrder = fmt.Sprintf("%s %s", opts.withSortColumn, dir)
+	}
+	if opts.withLimit > 0 && opts.withOrder == "" {
+		// Without an order the targets returned for a limit aren't
+		// deterministic.
+		opts.withOrder = "id asc"
+	}
+	if opts.withOrder != "" {
+		dbOpts = append(dbOpts, db.WithOrder(opts.withOrder))
+	}
+
+	if len(opts.withFields) > 0 {
+		ret, err := r.searchTargetFields(ctx, opts.withFields, condition, searchArgs, opts.withOrder, opts.withLimit)
+		if err != nil {
+			return nil, errors.Wrap(ctx, err, op)
+		}
+		return ret, nil
+	}
+
 	var cachedTargets []*Target
-	if err := r.rw.SearchWhere(ctx, &cachedTargets, condition, searchArgs, db.WithLimit(-1)); err != nil {
+	if err := r.rw.SearchWhere(ctx, &cachedTargets, condition, searchArgs, dbOpts...); err != nil {
 		return nil, errors.Wrap(ctx, err, op)
 	}
 
@@ -361,17 +1116,100 @@ func (r *Repository) searchTargets(ctx context.Context, condition string, search
 	return retTargets, nil
 }
 
+// targetField is a target field which can be selected with WithFields.
+type targetField struct {
+	// column is the expression read from the target table for the field
+	column string
+	// dest returns where in the target the read value is stored
+	dest func(*targets.Target) any
+}
+
+// targetFields are the target fields which can be selected with WithFields,
+// keyed by their column name.
+var targetFields = map[string]targetField{
+	"id":                  {"id", func(t *targets.Target) any { return &t.Id }},
+	"name":                {"coalesce(name, '')", func(t *targets.Target) any { return &t.Name }},
+	"description":         {"coalesce(description, '')", func(t *targets.Target) any { return &t.Description }},
+	"type":                {"coalesce(type, '')", func(t *targets.Target) any { return &t.Type }},
+	"address":             {"coalesce(address, '')", func(t *targets.Target) any { return &t.Address }},
+	"scope_id":            {"coalesce(scope_id, '')", func(t *targets.Target) any { return &t.ScopeId }},
+	"session_max_seconds": {"coalesce(session_max_seconds, 0)", func(t *targets.Target) any { return &t.SessionMaxSeconds }},
+}
+
+// searchTargetFields is like searchTargets but only reads the provided fields
+// from the targets matching the condition instead of their full item.
+func (r *Repository) searchTargetFields(ctx context.Context, fields []string, condition string, searchArgs []any, order string, limit int) ([]*targets.Target, error) {
+	const op = "cache.(Repository).searchTargetFields"
+	columns := make([]string, 0, len(fields))
+	for _, f := range fields {
+		tf, ok := targetFields[f]
+		if !ok {
+			return nil, errors.New(ctx, errors.InvalidParameter, op, fmt.Sprintf("%q is not a selectable target field", f))
+		}
+		columns = append(columns, tf.column)
+	}
+	query := fmt.Sprintf("select %s from target where %s", strings.Join(columns, ", "), condition)
+	if order != "" {
+		query = fmt.Sprintf("%s order by %s", query, order)
+	}
+	if limit > 0 {
+		query = fmt.Sprintf("%s limit %d", query, limit)
+	}
+
+	rows, err := r.rw.Query(ctx, query, searchArgs)
+	if err != nil {
+		return nil, errors.Wrap(ctx, err, op)
+	}
+	defer rows.Close()
+	ret := []*targets.Target{}
+	for rows.Next() {
+		var tar targets.Target
+		dests := make([]any, 0, len(fields))
+		for _, f := range fields {
+			dests = append(dests, targetFields[f].dest(&tar))
+		}
+		if err := rows.Scan(dests...); err != nil {
+			return nil, errors.Wrap(ctx, err, op)
+		}
+		ret = append(ret, &tar)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(ctx, err, op)
+	}
+	return ret, nil
+}
+
 type Target struct {
-	FkUserId    string `gorm:"primaryKey"`
-	Id          string `gorm:"primaryKey"`
-	Type        string `gorm:"default:null"`
-	Name        string `gorm:"default:null"`
-	Description string `gorm:"default:null"`
-	Address     string `gorm:"default:null"`
-	ScopeId     string `gorm:"default:null"`
-	Item        string `gorm:"default:null"`
+	FkUserId          string    `gorm:"primaryKey"`
+	Id                string    `gorm:"primaryKey"`
+	Type              string    `gorm:"default:null"`
+	Name              string    `gorm:"default:null"`
+	Description       string    `gorm:"default:null"`
+	Address           string    `gorm:"default:null"`
+	ScopeId           string    `gorm:"default:null"`
+	SessionMaxSeconds uint32    `gorm:"default:null"`
+	Item              string    `gorm:"default:null"`
+	FirstSeenTime     time.Time `gorm:"default:(strftime('%Y-%m-%d %H:%M:%f','now'))"`
 }
 
 func (*Target) TableName() string {
 	return "target"
 }
+
+// targetSubtypes are the target subtypes which cached targets can be filtered
+// by.
+var targetSubtypes = map[globals.Subtype]bool{
+	"tcp": true,
+	"ssh": true,
+}
+
+// targetSortColumns are the target columns which results can be sorted by.
+var targetSortColumns = map[string]bool{
+	"id":                  true,
+	"name":                true,
+	"description":         true,
+	"address":             true,
+	"scope_id":            true,
+	"type":                true,
+	"session_max_seconds": true,
+}
@@ -6,8 +6,12 @@ package cache
 import (
 	"context"
 	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"slices"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/boundary/api"
 	"github.com/hashicorp/boundary/api/authtokens"
@@ -16,6 +20,8 @@ import (
 	cachedb "github.com/hashicorp/boundary/internal/clientcache/internal/db"
 	"github.com/hashicorp/boundary/internal/daemon/controller"
 	"github.com/hashicorp/boundary/internal/db"
+	"github.com/hashicorp/boundary/internal/errors"
+	"github.com/hashicorp/boundary/internal/types/resource"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/exp/maps"
@@ -49,6 +55,7 @@ func TestRepository_refreshTargets(t *testing.T) {
 			Id:                "ttcp_1",
 			Name:              "name1",
 			Description:       "description1",
+			HostSourceIds:     []string{"hsst_1"},
 			Type:              "tcp",
 			ScopeId:           "p_123",
 			SessionMaxSeconds: 111,
@@ -75,14 +82,15 @@ func TestRepository_refreshTargets(t *testing.T) {
 		ti, err := json.Marshal(tar)
 		require.NoError(t, err)
 		want = append(want, &Target{
-			FkUserId:    u.Id,
-			Id:          tar.Id,
-			Name:        tar.Name,
-			Description: tar.Description,
-			Address:     tar.Address,
-			ScopeId:     tar.ScopeId,
-			Type:        tar.Type,
-			Item:        string(ti),
+			FkUserId:          u.Id,
+			Id:                tar.Id,
+			Name:              tar.Name,
+			Description:       tar.Description,
+			Address:           tar.Address,
+			ScopeId:           tar.ScopeId,
+			Type:              tar.Type,
+			SessionMaxSeconds: tar.SessionMaxSeconds,
+			Item:              string(ti),
 		})
 	}
 	cases := []struct {
@@ -110,17 +118,52 @@ func TestRepository_refreshTargets(t *testing.T) {
 				Id:      at.UserId,
 			},
 			targets: append(ts, &targets.Target{
-				Id:   ts[0].Id,
-				Name: "a different name",
+				Id:      ts[0].Id,
+				Name:    "a different name",
+				Address: "address1",
 			}),
 			want: append(want[1:],
 				&Target{
 					FkUserId: want[0].FkUserId,
 					Id:       want[0].Id,
 					Name:     "a different name",
-					Item:     `{"id":"ttcp_1","name":"a different name","created_time":"0001-01-01T00:00:00Z","updated_time":"0001-01-01T00:00:00Z"}`,
+					Address:  "address1",
+					Item:     `{"id":"ttcp_1","name":"a different name","created_time":"0001-01-01T00:00:00Z","updated_time":"0001-01-01T00:00:00Z","address":"address1"}`,
 				}),
 		},
+		{
+			name: "address is normalized",
+			u: &user{
+				Address: addr,
+				Id:      at.UserId,
+			},
+			targets: append(ts[1:], &targets.Target{
+				Id:      ts[0].Id,
+				Name:    ts[0].Name,
+				Address: "  Some.Host.EXAMPLE:22 \t",
+			}),
+			want: append(slices.Clip(want[1:]),
+				&Target{
+					FkUserId: want[0].FkUserId,
+					Id:       want[0].Id,
+					Name:     ts[0].Name,
+					Address:  "some.host.example:22",
+					Item:     `{"id":"ttcp_1","name":"name1","created_time":"0001-01-01T00:00:00Z","updated_time":"0001-01-01T00:00:00Z","address":"  Some.Host.EXAMPLE:22 \t"}`,
+				}),
+		},
+		{
+			name: "target without address or host sources is not cached",
+			u: &user{
+				Address: addr,
+				Id:      at.UserId,
+			},
+			targets: append(ts[1:], &targets.Target{
+				Id:      ts[0].Id,
+				Name:    ts[0].Name,
+				Address: "  ",
+			}),
+			want: want[1:],
+		},
 		{
 			name:          "nil user",
 			u:             nil,
@@ -139,13 +182,17 @@ func TestRepository_refreshTargets(t *testing.T) {
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			err := r.refreshTargets(ctx, tc.u, map[AuthToken]string{{Id: "id"}: "something"},
+			_, err := r.refreshTargets(ctx, tc.u, map[AuthToken]string{{Id: "id"}: "something"},
 				WithTargetRetrievalFunc(testStaticResourceRetrievalFunc(t, [][]*targets.Target{tc.targets}, [][]string{nil})))
 			if tc.errorContains == "" {
 				assert.NoError(t, err)
 				rw := db.New(s)
 				var got []*Target
 				require.NoError(t, rw.SearchWhere(ctx, &got, "true", nil))
+				for _, g := range got {
+					assert.False(t, g.FirstSeenTime.IsZero())
+					g.FirstSeenTime = time.Time{}
+				}
 				assert.ElementsMatch(t, got, tc.want)
 
 				t.Cleanup(func() {
@@ -163,6 +210,223 @@ func TestRepository_refreshTargets(t *testing.T) {
 	}
 }
 
+func TestRepository_refreshTargets_InvalidIds(t *testing.T) {
+	ctx := context.Background()
+	s, err := cachedb.Open(ctx)
+	require.NoError(t, err)
+
+	addr := "address"
+	u := &user{Id: "u1", Address: addr}
+	at := &authtokens.AuthToken{Id: "at_1", Token: "at_1_token", UserId: u.Id}
+	kt := KeyringToken{KeyringType: "k", TokenName: "t", AuthTokenId: at.Id}
+	atMap := map[ringToken]*authtokens.AuthToken{
+		{kt.KeyringType, kt.TokenName}: at,
+	}
+	r, err := NewRepository(ctx, s, &sync.Map{}, mapBasedAuthTokenKeyringLookup(atMap), sliceBasedAuthTokenBoundaryReader(maps.Values(atMap)))
+	require.NoError(t, err)
+	require.NoError(t, r.AddKeyringToken(ctx, addr, kt))
+
+	tcp := &targets.Target{Id: "ttcp_1", Address: "address1", Type: "tcp"}
+	ssh := &targets.Target{Id: "tssh_1", Address: "address2", Type: "ssh"}
+	ts := []*targets.Target{
+		tcp,
+		{Id: "hst_1", Address: "address3", Type: "tcp"},
+		ssh,
+		{Id: "ttcp1", Address: "address4", Type: "tcp"},
+		{Id: "", Address: "address5", Type: "tcp"},
+	}
+	_, err = r.refreshTargets(ctx, u, map[AuthToken]string{{Id: "id"}: "something"},
+		WithTargetRetrievalFunc(testStaticResourceRetrievalFunc(t, [][]*targets.Target{ts}, [][]string{nil})))
+	assert.ErrorIs(t, err, ErrSkippedInvalidIds)
+	assert.ErrorContains(t, err, "target ids: hst_1, ttcp1, ")
+
+	// The rest of the refresh is still committed.
+	got, err := r.ListTargets(ctx, at.Id, WithSort("id", AscendingSortDirection))
+	require.NoError(t, err)
+	assert.Equal(t, []*targets.Target{ssh, tcp}, got)
+
+	apiErr, err := r.lookupError(ctx, u, targetResourceType)
+	require.NoError(t, err)
+	require.NotNil(t, apiErr)
+	assert.Contains(t, apiErr.Error, "target ids: hst_1, ttcp1, ")
+}
+
+func TestPartitionTargetsById(t *testing.T) {
+	ctx := context.Background()
+	tcp := &targets.Target{Id: "ttcp_1"}
+	invalid := &targets.Target{Id: "hst_1"}
+
+	t.Run("target prefixes are registered", func(t *testing.T) {
+		assert.NotEmpty(t, resource.Target.Prefixes())
+	})
+	t.Run("valid and invalid ids", func(t *testing.T) {
+		valid, invalidIds, err := partitionTargetsById(ctx, []*targets.Target{tcp, nil, invalid}, resource.Target.Prefixes())
+		require.NoError(t, err)
+		assert.Equal(t, []*targets.Target{tcp}, valid)
+		assert.Equal(t, []string{invalid.Id}, invalidIds)
+	})
+	t.Run("no prefixes", func(t *testing.T) {
+		valid, invalidIds, err := partitionTargetsById(ctx, []*targets.Target{tcp, invalid}, nil)
+		assert.Truef(t, errors.Match(errors.T(errors.Internal), err), "unexpected error: %v", err)
+		assert.Nil(t, valid)
+		assert.Nil(t, invalidIds)
+	})
+}
+
+func TestRepository_RefreshAllTargets(t *testing.T) {
+	ctx := context.Background()
+	s, err := cachedb.Open(ctx)
+	require.NoError(t, err)
+
+	addr := "address"
+	u1 := &user{
+		Id:      "u1",
+		Address: addr,
+	}
+	at1 := &authtokens.AuthToken{
+		Id:     "at_1",
+		Token:  "at_1_token",
+		UserId: u1.Id,
+	}
+	kt1 := KeyringToken{KeyringType: "k1", TokenName: "t1", AuthTokenId: at1.Id}
+
+	u2 := &user{
+		Id:      "u2",
+		Address: addr,
+	}
+	at2 := &authtokens.AuthToken{
+		Id:     "at_2",
+		Token:  "at_2_token",
+		UserId: u2.Id,
+	}
+	kt2 := KeyringToken{KeyringType: "k2", TokenName: "t2", AuthTokenId: at2.Id}
+	atMap := map[ringToken]*authtokens.AuthToken{
+		{"k1", "t1"}: at1,
+		{"k2", "t2"}: at2,
+	}
+
+	var failingToken string
+	boundaryReader := sliceBasedAuthTokenBoundaryReader(maps.Values(atMap))
+	r, err := NewRepository(ctx, s, &sync.Map{}, mapBasedAuthTokenKeyringLookup(atMap),
+		func(ctx context.Context, addr, at string) (*authtokens.AuthToken, error) {
+			if at == failingToken {
+				return nil, stderrors.New("token lookup failure")
+			}
+			return boundaryReader(ctx, addr, at)
+		})
+	require.NoError(t, err)
+	require.NoError(t, r.AddKeyringToken(ctx, addr, kt1))
+	require.NoError(t, r.AddKeyringToken(ctx, addr, kt2))
+
+	ts := []*targets.Target{
+		target("1"),
+		target("2"),
+	}
+	retrievalFn := testStaticResourceRetrievalFunc(t, [][]*targets.Target{ts}, [][]string{nil})
+
+	t.Run("token lookup fails for one user", func(t *testing.T) {
+		failingToken = at2.Token
+		t.Cleanup(func() { failingToken = "" })
+
+		err := r.RefreshAllTargets(ctx, WithTargetRetrievalFunc(retrievalFn))
+		assert.ErrorContains(t, err, "token lookup failure")
+		assert.ErrorContains(t, err, u2.Id)
+
+		l, err := r.ListTargets(ctx, at1.Id)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, ts, l)
+
+		l, err = r.ListTargets(ctx, at2.Id)
+		require.NoError(t, err)
+		assert.Empty(t, l)
+	})
+
+	t.Run("retrieval fails for one user", func(t *testing.T) {
+		err := r.RefreshAllTargets(ctx, WithTargetRetrievalFunc(
+			func(ctx context.Context, addr, authTok string, refreshTok RefreshTokenValue) ([]*targets.Target, []string, RefreshTokenValue, error) {
+				if authTok == at1.Token {
+					return nil, nil, "", stderrors.New("retrieval failure")
+				}
+				return retrievalFn(ctx, addr, authTok, refreshTok)
+			}))
+		assert.ErrorContains(t, err, "retrieval failure")
+		assert.ErrorContains(t, err, u1.Id)
+
+		// the targets cached for the first user are left untouched
+		l, err := r.ListTargets(ctx, at1.Id)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, ts, l)
+
+		l, err = r.ListTargets(ctx, at2.Id)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, ts, l)
+	})
+}
+
+// cancelingMarshaler cancels the provided context when it is marshaled to json.
+type cancelingMarshaler struct {
+	cancel context.CancelFunc
+}
+
+func (m cancelingMarshaler) MarshalJSON() ([]byte, error) {
+	m.cancel()
+	return []byte(`"canceled"`), nil
+}
+
+func TestRepository_RefreshTargets_Canceled(t *testing.T) {
+	ctx := context.Background()
+	s, err := cachedb.Open(ctx)
+	require.NoError(t, err)
+
+	addr := "address"
+	u := &user{
+		Id:      "u1",
+		Address: addr,
+	}
+	at := &authtokens.AuthToken{
+		Id:     "at_1",
+		Token:  "at_1_token",
+		UserId: u.Id,
+	}
+	kt := KeyringToken{KeyringType: "k", TokenName: "t", AuthTokenId: at.Id}
+	atMap := map[ringToken]*authtokens.AuthToken{
+		{kt.KeyringType, kt.TokenName}: at,
+	}
+	r, err := NewRepository(ctx, s, &sync.Map{}, mapBasedAuthTokenKeyringLookup(atMap), sliceBasedAuthTokenBoundaryReader(maps.Values(atMap)))
+	require.NoError(t, err)
+	require.NoError(t, r.AddKeyringToken(ctx, addr, kt))
+
+	old := []*targets.Target{
+		target("1"),
+		target("2"),
+		target("3"),
+	}
+	_, err = r.refreshTargets(ctx, u, map[AuthToken]string{{Id: "id"}: "something"},
+		WithTargetRetrievalFunc(testStaticResourceRetrievalFunc(t, [][]*targets.Target{old}, [][]string{nil})))
+	require.NoError(t, err)
+	// Remove the refresh token so the next refresh replaces all the targets
+	require.NoError(t, r.deleteRefreshToken(ctx, u, targetResourceType))
+
+	cancelCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// The context is canceled while the second of the new targets is being
+	// upserted.
+	canceling := target("5")
+	canceling.Attributes = map[string]any{"cancel": cancelingMarshaler{cancel: cancel}}
+	replacement := []*targets.Target{
+		target("4"),
+		canceling,
+		target("6"),
+	}
+	_, err = r.refreshTargets(cancelCtx, u, map[AuthToken]string{{Id: "id"}: "something"},
+		WithTargetRetrievalFunc(testStaticResourceRetrievalFunc(t, [][]*targets.Target{replacement}, [][]string{nil})))
+	assert.Error(t, err)
+
+	got, err := r.ListTargets(ctx, at.Id)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, old, got)
+}
+
 func TestRepository_RefreshTargets_InvalidListTokenError(t *testing.T) {
 	ctx := context.Background()
 	s, err := cachedb.Open(ctx)
@@ -211,13 +475,15 @@ func TestRepository_RefreshTargets_InvalidListTokenError(t *testing.T) {
 		return testStaticResourceRetrievalFunc(t, ts, [][]string{nil})(ctx, addr, authTok, refreshTok)
 	}
 
-	require.NoError(t, r.refreshTargets(ctx, &u, map[AuthToken]string{{Id: "id"}: "something"},
-		WithTargetRetrievalFunc(invalidAuthTokenFunc)))
+	_, err = r.refreshTargets(ctx, &u, map[AuthToken]string{{Id: "id"}: "something"},
+		WithTargetRetrievalFunc(invalidAuthTokenFunc))
+	require.NoError(t, err)
 
 	// This time an invalid auth token should be returned, and refreshTargets should fall back
 	// to requesting without one.
-	require.NoError(t, r.refreshTargets(ctx, &u, map[AuthToken]string{{Id: "id"}: "something"},
-		WithTargetRetrievalFunc(invalidAuthTokenFunc)))
+	_, err = r.refreshTargets(ctx, &u, map[AuthToken]string{{Id: "id"}: "something"},
+		WithTargetRetrievalFunc(invalidAuthTokenFunc))
+	require.NoError(t, err)
 
 	assert.Equal(t, 1, withRefreshToken)
 	assert.Equal(t, 2, withoutRefreshToken)
@@ -259,8 +525,9 @@ func TestRepository_RefreshTargets_withRefreshTokens(t *testing.T) {
 		},
 	}
 
-	require.NoError(t, r.refreshTargets(ctx, &u, map[AuthToken]string{{Id: "id"}: "something"},
-		WithTargetRetrievalFunc(testStaticResourceRetrievalFunc(t, ts, [][]string{nil, nil}))))
+	_, err = r.refreshTargets(ctx, &u, map[AuthToken]string{{Id: "id"}: "something"},
+		WithTargetRetrievalFunc(testStaticResourceRetrievalFunc(t, ts, [][]string{nil, nil})))
+	require.NoError(t, err)
 
 	got, err := r.ListTargets(ctx, at.Id)
 	require.NoError(t, err)
@@ -268,8 +535,9 @@ func TestRepository_RefreshTargets_withRefreshTokens(t *testing.T) {
 
 	// Refreshing again uses the refresh token and get additional sessions, appending
 	// them to the response
-	require.NoError(t, r.refreshTargets(ctx, &u, map[AuthToken]string{{Id: "id"}: "something"},
-		WithTargetRetrievalFunc(testStaticResourceRetrievalFunc(t, ts, [][]string{nil, nil}))))
+	_, err = r.refreshTargets(ctx, &u, map[AuthToken]string{{Id: "id"}: "something"},
+		WithTargetRetrievalFunc(testStaticResourceRetrievalFunc(t, ts, [][]string{nil, nil})))
+	require.NoError(t, err)
 	assert.NoError(t, err)
 
 	got, err = r.ListTargets(ctx, at.Id)
@@ -278,8 +546,9 @@ func TestRepository_RefreshTargets_withRefreshTokens(t *testing.T) {
 
 	// Refreshing again wont return any more resources, but also none should be
 	// removed
-	require.NoError(t, r.refreshTargets(ctx, &u, map[AuthToken]string{{Id: "id"}: "something"},
-		WithTargetRetrievalFunc(testStaticResourceRetrievalFunc(t, ts, [][]string{nil, nil}))))
+	_, err = r.refreshTargets(ctx, &u, map[AuthToken]string{{Id: "id"}: "something"},
+		WithTargetRetrievalFunc(testStaticResourceRetrievalFunc(t, ts, [][]string{nil, nil})))
+	require.NoError(t, err)
 	assert.NoError(t, err)
 
 	got, err = r.ListTargets(ctx, at.Id)
@@ -287,8 +556,9 @@ func TestRepository_RefreshTargets_withRefreshTokens(t *testing.T) {
 	assert.Len(t, got, 3)
 
 	// Refresh again with the refresh token being reported as invalid.
-	require.NoError(t, r.refreshTargets(ctx, &u, map[AuthToken]string{{Id: "id"}: "something"},
-		WithTargetRetrievalFunc(testErroringForRefreshTokenRetrievalFunc(t, ts[0]))))
+	_, err = r.refreshTargets(ctx, &u, map[AuthToken]string{{Id: "id"}: "something"},
+		WithTargetRetrievalFunc(testErroringForRefreshTokenRetrievalFunc(t, ts[0])))
+	require.NoError(t, err)
 	assert.NoError(t, err)
 
 	got, err = r.ListTargets(ctx, at.Id)
@@ -343,8 +613,9 @@ func TestRepository_ListTargets(t *testing.T) {
 		target("2"),
 		target("3"),
 	}
-	require.NoError(t, r.refreshTargets(ctx, u1, map[AuthToken]string{{Id: "id"}: "something"},
-		WithTargetRetrievalFunc(testStaticResourceRetrievalFunc(t, [][]*targets.Target{ts}, [][]string{nil}))))
+	_, err = r.refreshTargets(ctx, u1, map[AuthToken]string{{Id: "id"}: "something"},
+		WithTargetRetrievalFunc(testStaticResourceRetrievalFunc(t, [][]*targets.Target{ts}, [][]string{nil})))
+	require.NoError(t, err)
 
 	t.Run("wrong user gets no targets", func(t *testing.T) {
 		l, err := r.ListTargets(ctx, kt2.AuthTokenId)
@@ -357,9 +628,41 @@ func TestRepository_ListTargets(t *testing.T) {
 		assert.Len(t, l, len(ts))
 		assert.ElementsMatch(t, l, ts)
 	})
+	t.Run("filtered by scope", func(t *testing.T) {
+		l, err := r.ListTargets(ctx, kt1.AuthTokenId, WithScopeId(ts[1].ScopeId))
+		assert.NoError(t, err)
+		assert.Equal(t, []*targets.Target{ts[1]}, l)
+	})
+	t.Run("empty scope filter gets all scopes", func(t *testing.T) {
+		l, err := r.ListTargets(ctx, kt1.AuthTokenId, WithScopeId(""))
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, ts, l)
+	})
+	t.Run("sorted by name ascending", func(t *testing.T) {
+		l, err := r.ListTargets(ctx, kt1.AuthTokenId, WithSort("name", AscendingSortDirection))
+		assert.NoError(t, err)
+		assert.Equal(t, []*targets.Target{ts[0], ts[1], ts[2]}, l)
+	})
+	t.Run("sorted by name descending", func(t *testing.T) {
+		l, err := r.ListTargets(ctx, kt1.AuthTokenId, WithSort("name", DescendingSortDirection))
+		assert.NoError(t, err)
+		assert.Equal(t, []*targets.Target{ts[2], ts[1], ts[0]}, l)
+	})
+	t.Run("unsortable column", func(t *testing.T) {
+		l, err := r.ListTargets(ctx, kt1.AuthTokenId, WithSort("item", AscendingSortDirection))
+		assert.Nil(t, l)
+		assert.Truef(t, errors.Match(errors.T(errors.InvalidParameter), err), "unexpected error: %v", err)
+		assert.ErrorContains(t, err, `"item" is not a sortable target column`)
+	})
+	t.Run("unknown sort direction", func(t *testing.T) {
+		l, err := r.ListTargets(ctx, kt1.AuthTokenId, WithSort("name", "sideways"))
+		assert.Nil(t, l)
+		assert.Truef(t, errors.Match(errors.T(errors.InvalidParameter), err), "unexpected error: %v", err)
+		assert.ErrorContains(t, err, `unknown sort direction "sideways"`)
+	})
 }
 
-func TestRepository_QueryTargets(t *testing.T) {
+func TestRepository_GetTarget(t *testing.T) {
 	ctx := context.Background()
 	s, err := cachedb.Open(ctx)
 	require.NoError(t, err)
@@ -375,7 +678,6 @@ func TestRepository_QueryTargets(t *testing.T) {
 		UserId: u1.Id,
 	}
 	kt1 := KeyringToken{KeyringType: "k1", TokenName: "t1", AuthTokenId: at1.Id}
-
 	u2 := &user{
 		Id:      "u2",
 		Address: addr,
@@ -395,100 +697,1006 @@ func TestRepository_QueryTargets(t *testing.T) {
 	require.NoError(t, r.AddKeyringToken(ctx, addr, kt1))
 	require.NoError(t, r.AddKeyringToken(ctx, addr, kt2))
 
-	query := `(name % 'name1' or name % 'name2') and scope_id = "p_123"`
-
-	errorCases := []struct {
-		name        string
-		p           string
-		query       string
-		errContains string
-	}{
-		{
-			name:        "auth token id is missing",
-			p:           "",
-			query:       query,
-			errContains: "auth token id is missing",
-		},
-		{
-			name:        "query is missing",
-			p:           "authtokenid",
-			errContains: "query is missing",
-		},
-	}
-
-	for _, tc := range errorCases {
-		t.Run(tc.name, func(t *testing.T) {
-			l, err := r.QueryTargets(ctx, tc.p, tc.query)
-			assert.Nil(t, l)
-			assert.ErrorContains(t, err, tc.errContains)
-		})
-	}
-
 	ts := []*targets.Target{
-		{
-			Id:                "ttcp_1",
-			Name:              "name1",
-			Address:           "address1",
-			Type:              "tcp",
-			ScopeId:           "p_123",
-			SessionMaxSeconds: 111,
-		},
-		{
-			Id:                "ttcp_2",
-			Name:              "name2",
-			Address:           "address2",
-			Type:              "tcp",
-			ScopeId:           "p_123",
-			SessionMaxSeconds: 222,
-		},
-		{
-			Id:                "ttcp_3",
-			Name:              "name3",
-			Address:           "address3",
-			Type:              "tcp",
-			ScopeId:           "p_123",
-			SessionMaxSeconds: 333,
-		},
+		target("1"),
+		target("2"),
 	}
-	require.NoError(t, r.refreshTargets(ctx, u1, map[AuthToken]string{{Id: "id"}: "something"},
-		WithTargetRetrievalFunc(testStaticResourceRetrievalFunc(t, [][]*targets.Target{ts}, [][]string{nil}))))
+	_, err = r.refreshTargets(ctx, u1, map[AuthToken]string{{Id: "id"}: "something"},
+		WithTargetRetrievalFunc(testStaticResourceRetrievalFunc(t, [][]*targets.Target{ts}, [][]string{nil})))
+	require.NoError(t, err)
 
-	t.Run("wrong token gets no targets", func(t *testing.T) {
-		l, err := r.QueryTargets(ctx, kt2.AuthTokenId, query)
-		assert.NoError(t, err)
-		assert.Empty(t, l)
+	t.Run("auth token id is missing", func(t *testing.T) {
+		got, err := r.GetTarget(ctx, "", ts[0].Id)
+		assert.Nil(t, got)
+		assert.Truef(t, errors.Match(errors.T(errors.InvalidParameter), err), "unexpected error: %v", err)
 	})
-	t.Run("correct token gets targets", func(t *testing.T) {
-		l, err := r.QueryTargets(ctx, kt1.AuthTokenId, query)
-		assert.NoError(t, err)
-		assert.Len(t, l, 2)
-		assert.ElementsMatch(t, l, ts[0:2])
+	t.Run("target id is missing", func(t *testing.T) {
+		got, err := r.GetTarget(ctx, kt1.AuthTokenId, "")
+		assert.Nil(t, got)
+		assert.Truef(t, errors.Match(errors.T(errors.InvalidParameter), err), "unexpected error: %v", err)
 	})
-}
-
-func TestDefaultTargetRetrievalFunc(t *testing.T) {
-	oldDur := globals.RefreshReadLookbackDuration
-	globals.RefreshReadLookbackDuration = 0
-	t.Cleanup(func() {
-		globals.RefreshReadLookbackDuration = oldDur
+	t.Run("found", func(t *testing.T) {
+		got, err := r.GetTarget(ctx, kt1.AuthTokenId, ts[1].Id)
+		require.NoError(t, err)
+		assert.Equal(t, ts[1], got)
 	})
+	t.Run("not found", func(t *testing.T) {
+		got, err := r.GetTarget(ctx, kt1.AuthTokenId, "target_unknown")
+		assert.Nil(t, got)
+		assert.Truef(t, errors.Match(errors.T(errors.NotFound), err), "unexpected error: %v", err)
+	})
+	t.Run("wrong user", func(t *testing.T) {
+		got, err := r.GetTarget(ctx, kt2.AuthTokenId, ts[0].Id)
+		assert.Nil(t, got)
+		assert.Truef(t, errors.Match(errors.T(errors.NotFound), err), "unexpected error: %v", err)
+	})
+}
 
-	tc := controller.NewTestController(t, nil)
-	tc.Client().SetToken(tc.Token().Token)
-	tarClient := targets.NewClient(tc.Client())
-
-	tar1, err := tarClient.Create(tc.Context(), "tcp", "p_1234567890", targets.WithName("tar1"), targets.WithTcpTargetDefaultPort(1))
-	require.NoError(t, err)
-	require.NotNil(t, tar1)
-	tar2, err := tarClient.Create(tc.Context(), "tcp", "p_1234567890", targets.WithName("tar2"), targets.WithTcpTargetDefaultPort(2))
+func TestRepository_TargetTypeFilter(t *testing.T) {
+	ctx := context.Background()
+	s, err := cachedb.Open(ctx)
 	require.NoError(t, err)
-	require.NotNil(t, tar2)
 
-	got, removed, refTok, err := defaultTargetFunc(tc.Context(), tc.ApiAddrs()[0], tc.Token().Token, "")
-	assert.NoError(t, err)
-	assert.NotEmpty(t, refTok)
-	assert.Empty(t, removed)
-	found1 := false
+	addr := "address"
+	u := &user{
+		Id:      "u1",
+		Address: addr,
+	}
+	at := &authtokens.AuthToken{
+		Id:     "at_1",
+		Token:  "at_1_token",
+		UserId: u.Id,
+	}
+	kt := KeyringToken{KeyringType: "k", TokenName: "t", AuthTokenId: at.Id}
+	atMap := map[ringToken]*authtokens.AuthToken{
+		{kt.KeyringType, kt.TokenName}: at,
+	}
+	r, err := NewRepository(ctx, s, &sync.Map{}, mapBasedAuthTokenKeyringLookup(atMap), sliceBasedAuthTokenBoundaryReader(maps.Values(atMap)))
+	require.NoError(t, err)
+	require.NoError(t, r.AddKeyringToken(ctx, addr, kt))
+
+	tcp := target("1")
+	ssh := target("2")
+	ssh.Type = "ssh"
+	_, err = r.refreshTargets(ctx, u, map[AuthToken]string{{Id: "id"}: "something"},
+		WithTargetRetrievalFunc(testStaticResourceRetrievalFunc(t, [][]*targets.Target{{tcp, ssh}}, [][]string{nil})))
+	require.NoError(t, err)
+
+	t.Run("list tcp", func(t *testing.T) {
+		l, err := r.ListTargets(ctx, at.Id, WithTargetType("tcp"))
+		require.NoError(t, err)
+		assert.Equal(t, []*targets.Target{tcp}, l)
+	})
+	t.Run("list ssh", func(t *testing.T) {
+		l, err := r.ListTargets(ctx, at.Id, WithTargetType("ssh"))
+		require.NoError(t, err)
+		assert.Equal(t, []*targets.Target{ssh}, l)
+	})
+	t.Run("query ssh", func(t *testing.T) {
+		l, err := r.QueryTargets(ctx, at.Id, `name % 'name'`, WithTargetType("ssh"))
+		require.NoError(t, err)
+		assert.Equal(t, []*targets.Target{ssh}, l)
+	})
+	t.Run("unknown type", func(t *testing.T) {
+		l, err := r.ListTargets(ctx, at.Id, WithTargetType("rdp"))
+		assert.Nil(t, l)
+		assert.Truef(t, errors.Match(errors.T(errors.InvalidParameter), err), "unexpected error: %v", err)
+		assert.ErrorContains(t, err, `unknown target type "rdp"`)
+	})
+}
+
+func TestRepository_ListTargetsPage(t *testing.T) {
+	ctx := context.Background()
+	s, err := cachedb.Open(ctx)
+	require.NoError(t, err)
+
+	addr := "address"
+	u1 := &user{
+		Id:      "u1",
+		Address: addr,
+	}
+	at1 := &authtokens.AuthToken{
+		Id:     "at_1",
+		Token:  "at_1_token",
+		UserId: u1.Id,
+	}
+	kt1 := KeyringToken{KeyringType: "k1", TokenName: "t1", AuthTokenId: at1.Id}
+
+	u2 := &user{
+		Id:      "u2",
+		Address: addr,
+	}
+	at2 := &authtokens.AuthToken{
+		Id:     "at_2",
+		Token:  "at_2_token",
+		UserId: u2.Id,
+	}
+	kt2 := KeyringToken{KeyringType: "k2", TokenName: "t2", AuthTokenId: at2.Id}
+	atMap := map[ringToken]*authtokens.AuthToken{
+		{"k1", "t1"}: at1,
+		{"k2", "t2"}: at2,
+	}
+	r, err := NewRepository(ctx, s, &sync.Map{}, mapBasedAuthTokenKeyringLookup(atMap), sliceBasedAuthTokenBoundaryReader(maps.Values(atMap)))
+	require.NoError(t, err)
+	require.NoError(t, r.AddKeyringToken(ctx, addr, kt1))
+	require.NoError(t, r.AddKeyringToken(ctx, addr, kt2))
+
+	t.Run("token is missing", func(t *testing.T) {
+		l, next, err := r.ListTargetsPage(ctx, "", 1, "")
+		assert.Nil(t, l)
+		assert.Empty(t, next)
+		assert.ErrorContains(t, err, "auth token id is missing")
+	})
+	t.Run("page size is invalid", func(t *testing.T) {
+		l, next, err := r.ListTargetsPage(ctx, kt1.AuthTokenId, 0, "")
+		assert.Nil(t, l)
+		assert.Empty(t, next)
+		assert.ErrorContains(t, err, "page size must be greater than 0")
+	})
+
+	// refreshed out of order to ensure the pages are ordered by id
+	ts := []*targets.Target{
+		target("3"),
+		target("5"),
+		target("1"),
+		target("4"),
+		target("2"),
+	}
+	_, err = r.refreshTargets(ctx, u1, map[AuthToken]string{{Id: "id"}: "something"},
+		WithTargetRetrievalFunc(testStaticResourceRetrievalFunc(t, [][]*targets.Target{ts}, [][]string{nil})))
+	require.NoError(t, err)
+
+	t.Run("wrong user gets no targets", func(t *testing.T) {
+		l, next, err := r.ListTargetsPage(ctx, kt2.AuthTokenId, 2, "")
+		assert.NoError(t, err)
+		assert.Empty(t, l)
+		assert.Empty(t, next)
+	})
+	t.Run("walk all pages", func(t *testing.T) {
+		var got [][]*targets.Target
+		var pageToken string
+		for {
+			l, next, err := r.ListTargetsPage(ctx, kt1.AuthTokenId, 2, pageToken)
+			require.NoError(t, err)
+			got = append(got, l)
+			if next == "" {
+				break
+			}
+			pageToken = next
+		}
+		assert.Equal(t, [][]*targets.Target{
+			{target("1"), target("2")},
+			{target("3"), target("4")},
+			{target("5")},
+		}, got)
+	})
+	t.Run("final empty page", func(t *testing.T) {
+		l, next, err := r.ListTargetsPage(ctx, kt1.AuthTokenId, 2, target("5").Id)
+		require.NoError(t, err)
+		assert.Empty(t, l)
+		assert.Empty(t, next)
+	})
+}
+
+func TestRepository_ListTargetsWithFields(t *testing.T) {
+	ctx := context.Background()
+	s, err := cachedb.Open(ctx)
+	require.NoError(t, err)
+
+	addr := "address"
+	u := &user{Id: "u1", Address: addr}
+	at := &authtokens.AuthToken{Id: "at_1", Token: "at_1_token", UserId: u.Id}
+	kt := KeyringToken{KeyringType: "k1", TokenName: "t1", AuthTokenId: at.Id}
+	atMap := map[ringToken]*authtokens.AuthToken{
+		{kt.KeyringType, kt.TokenName}: at,
+	}
+	r, err := NewRepository(ctx, s, &sync.Map{}, mapBasedAuthTokenKeyringLookup(atMap), sliceBasedAuthTokenBoundaryReader(maps.Values(atMap)))
+	require.NoError(t, err)
+	require.NoError(t, r.AddKeyringToken(ctx, addr, kt))
+
+	ts := []*targets.Target{target("1"), target("2"), target("3")}
+	ts[2].Description = ""
+	_, err = r.refreshTargets(ctx, u, map[AuthToken]string{{Id: "id"}: "something"},
+		WithTargetRetrievalFunc(testStaticResourceRetrievalFunc(t, [][]*targets.Target{ts}, [][]string{nil})))
+	require.NoError(t, err)
+
+	t.Run("id and name", func(t *testing.T) {
+		got, err := r.ListTargets(ctx, at.Id, WithFields("id", "name"), WithSort("id", AscendingSortDirection))
+		require.NoError(t, err)
+		want := make([]*targets.Target, 0, len(ts))
+		for _, tar := range ts {
+			want = append(want, &targets.Target{Id: tar.Id, Name: tar.Name})
+		}
+		assert.Equal(t, want, got)
+	})
+	t.Run("all selectable fields", func(t *testing.T) {
+		got, err := r.ListTargets(ctx, at.Id, WithFields("id", "name", "description", "type", "address", "scope_id", "session_max_seconds"),
+			WithSort("id", DescendingSortDirection), WithLimit(1))
+		require.NoError(t, err)
+		assert.Equal(t, []*targets.Target{ts[2]}, got)
+	})
+	t.Run("no fields", func(t *testing.T) {
+		got, err := r.ListTargets(ctx, at.Id, WithFields(), WithSort("id", AscendingSortDirection))
+		require.NoError(t, err)
+		assert.Equal(t, ts, got)
+	})
+	t.Run("unknown field", func(t *testing.T) {
+		got, err := r.ListTargets(ctx, at.Id, WithFields("id", "item"))
+		assert.Nil(t, got)
+		assert.Truef(t, errors.Match(errors.T(errors.InvalidParameter), err), "unexpected error: %v", err)
+		assert.ErrorContains(t, err, `"item" is not a selectable target field`)
+	})
+}
+
+func TestRepository_TargetStaleness(t *testing.T) {
+	ctx := context.Background()
+	s, err := cachedb.Open(ctx)
+	require.NoError(t, err)
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	addr := "address"
+	u1 := &user{
+		Id:      "u1",
+		Address: addr,
+	}
+	at1 := &authtokens.AuthToken{
+		Id:     "at_1",
+		Token:  "at_1_token",
+		UserId: u1.Id,
+	}
+	kt1 := KeyringToken{KeyringType: "k1", TokenName: "t1", AuthTokenId: at1.Id}
+
+	u2 := &user{
+		Id:      "u2",
+		Address: addr,
+	}
+	at2 := &authtokens.AuthToken{
+		Id:     "at_2",
+		Token:  "at_2_token",
+		UserId: u2.Id,
+	}
+	kt2 := KeyringToken{KeyringType: "k2", TokenName: "t2", AuthTokenId: at2.Id}
+	atMap := map[ringToken]*authtokens.AuthToken{
+		{"k1", "t1"}: at1,
+		{"k2", "t2"}: at2,
+	}
+	r, err := NewRepository(ctx, s, &sync.Map{}, mapBasedAuthTokenKeyringLookup(atMap), sliceBasedAuthTokenBoundaryReader(maps.Values(atMap)), withClock(clock))
+	require.NoError(t, err)
+	require.NoError(t, r.AddKeyringToken(ctx, addr, kt1))
+	require.NoError(t, r.AddKeyringToken(ctx, addr, kt2))
+
+	ts := []*targets.Target{
+		target("1"),
+		target("2"),
+	}
+	_, err = r.refreshTargets(ctx, u1, map[AuthToken]string{{Id: "id"}: "something"},
+		WithTargetRetrievalFunc(testStaticResourceRetrievalFunc(t, [][]*targets.Target{ts}, [][]string{nil})))
+	require.NoError(t, err)
+
+	t.Run("negative max age", func(t *testing.T) {
+		l, err := r.ListTargets(ctx, kt1.AuthTokenId, WithMaxAge(-time.Hour))
+		assert.Nil(t, l)
+		assert.Truef(t, errors.Match(errors.T(errors.InvalidParameter), err), "unexpected error: %v", err)
+
+		u, err := r.StaleUsers(ctx, -time.Hour)
+		assert.Nil(t, u)
+		assert.Truef(t, errors.Match(errors.T(errors.InvalidParameter), err), "unexpected error: %v", err)
+	})
+
+	t.Run("fresh before ttl elapses", func(t *testing.T) {
+		now = now.Add(30 * time.Minute)
+		l, err := r.ListTargets(ctx, kt1.AuthTokenId, WithMaxAge(time.Hour))
+		require.NoError(t, err)
+		assert.ElementsMatch(t, ts, l)
+
+		u, err := r.StaleUsers(ctx, time.Hour)
+		require.NoError(t, err)
+		assert.Empty(t, u)
+	})
+
+	t.Run("stale after ttl elapses", func(t *testing.T) {
+		now = now.Add(time.Hour)
+		l, err := r.ListTargets(ctx, kt1.AuthTokenId, WithMaxAge(time.Hour))
+		require.NoError(t, err)
+		assert.Empty(t, l)
+
+		// without a max age the stale targets are still returned
+		l, err = r.ListTargets(ctx, kt1.AuthTokenId)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, ts, l)
+
+		u, err := r.StaleUsers(ctx, time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, []string{u1.Id}, u)
+	})
+
+	t.Run("fresh after refresh", func(t *testing.T) {
+		// an incremental refresh with no changes still marks the targets as fresh
+		_, err = r.refreshTargets(ctx, u1, map[AuthToken]string{{Id: "id"}: "something"},
+			WithTargetRetrievalFunc(testStaticResourceRetrievalFunc(t, [][]*targets.Target{nil}, [][]string{nil})))
+		require.NoError(t, err)
+
+		l, err := r.ListTargets(ctx, kt1.AuthTokenId, WithMaxAge(time.Hour))
+		require.NoError(t, err)
+		assert.ElementsMatch(t, ts, l)
+
+		u, err := r.StaleUsers(ctx, time.Hour)
+		require.NoError(t, err)
+		assert.Empty(t, u)
+	})
+}
+
+func TestRepository_RawTokenTargets(t *testing.T) {
+	ctx := context.Background()
+	s, err := cachedb.Open(ctx)
+	require.NoError(t, err)
+
+	addr := "address"
+	u := &user{
+		Id:      "u1",
+		Address: addr,
+	}
+	rawAt := &authtokens.AuthToken{
+		Id:             "at_1",
+		Token:          "at_1_token",
+		UserId:         u.Id,
+		ExpirationTime: time.Now().Add(time.Minute),
+	}
+	keyringAt := &authtokens.AuthToken{
+		Id:             "at_2",
+		Token:          "at_2_token",
+		UserId:         u.Id,
+		ExpirationTime: time.Now().Add(time.Minute),
+	}
+	kt := KeyringToken{KeyringType: "k", TokenName: "t", AuthTokenId: keyringAt.Id}
+	atMap := map[ringToken]*authtokens.AuthToken{
+		{kt.KeyringType, kt.TokenName}: keyringAt,
+	}
+	r, err := NewRepository(ctx, s, &sync.Map{}, mapBasedAuthTokenKeyringLookup(atMap),
+		sliceBasedAuthTokenBoundaryReader([]*authtokens.AuthToken{rawAt, keyringAt}))
+	require.NoError(t, err)
+	require.NoError(t, r.AddRawToken(ctx, addr, rawAt.Token))
+	require.NoError(t, r.AddKeyringToken(ctx, addr, kt))
+
+	ts := []*targets.Target{
+		target("1"),
+		target("2"),
+	}
+	require.NoError(t, r.RefreshAllTargets(ctx,
+		WithTargetRetrievalFunc(testStaticResourceRetrievalFunc(t, [][]*targets.Target{ts}, [][]string{nil}))))
+
+	// Deleting the keyring token leaves the raw token and so the user's
+	// cached targets in place.
+	require.NoError(t, r.DeleteKeyringToken(ctx, kt))
+
+	t.Run("list", func(t *testing.T) {
+		l, err := r.ListTargets(ctx, rawAt.Id)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, ts, l)
+	})
+	t.Run("query", func(t *testing.T) {
+		l, err := r.QueryTargets(ctx, rawAt.Id, fmt.Sprintf("name = %q", ts[0].Name))
+		require.NoError(t, err)
+		assert.Equal(t, ts[:1], l)
+	})
+}
+
+func TestRepository_QueryTargets(t *testing.T) {
+	ctx := context.Background()
+	s, err := cachedb.Open(ctx)
+	require.NoError(t, err)
+
+	addr := "address"
+	u1 := &user{
+		Id:      "u1",
+		Address: addr,
+	}
+	at1 := &authtokens.AuthToken{
+		Id:     "at_1",
+		Token:  "at_1_token",
+		UserId: u1.Id,
+	}
+	kt1 := KeyringToken{KeyringType: "k1", TokenName: "t1", AuthTokenId: at1.Id}
+
+	u2 := &user{
+		Id:      "u2",
+		Address: addr,
+	}
+	at2 := &authtokens.AuthToken{
+		Id:     "at_2",
+		Token:  "at_2_token",
+		UserId: u2.Id,
+	}
+	kt2 := KeyringToken{KeyringType: "k2", TokenName: "t2", AuthTokenId: at2.Id}
+	atMap := map[ringToken]*authtokens.AuthToken{
+		{"k1", "t1"}: at1,
+		{"k2", "t2"}: at2,
+	}
+	r, err := NewRepository(ctx, s, &sync.Map{}, mapBasedAuthTokenKeyringLookup(atMap), sliceBasedAuthTokenBoundaryReader(maps.Values(atMap)))
+	require.NoError(t, err)
+	require.NoError(t, r.AddKeyringToken(ctx, addr, kt1))
+	require.NoError(t, r.AddKeyringToken(ctx, addr, kt2))
+
+	query := `(name % 'name1' or name % 'name2') and scope_id = "p_123"`
+
+	errorCases := []struct {
+		name        string
+		p           string
+		query       string
+		errContains string
+	}{
+		{
+			name:        "auth token id is missing",
+			p:           "",
+			query:       query,
+			errContains: "auth token id is missing",
+		},
+		{
+			name:        "query is missing",
+			p:           "authtokenid",
+			errContains: "query is missing",
+		},
+	}
+
+	for _, tc := range errorCases {
+		t.Run(tc.name, func(t *testing.T) {
+			l, err := r.QueryTargets(ctx, tc.p, tc.query)
+			assert.Nil(t, l)
+			assert.ErrorContains(t, err, tc.errContains)
+		})
+	}
+
+	invalidQueryCases := []struct {
+		name        string
+		query       string
+		errContains string
+	}{
+		{
+			name:        "unknown field",
+			query:       `nickname % 'name1'`,
+			errContains: `invalid column "nickname"`,
+		},
+		{
+			name:        "field which can't be queried",
+			query:       `name % 'name1' and item % 'secret'`,
+			errContains: `invalid column "item"`,
+		},
+		{
+			name:        "internal field",
+			query:       `fk_user_id = 'u1'`,
+			errContains: `invalid column "fk_user_id"`,
+		},
+		{
+			name:        "value of the wrong type",
+			query:       `(name = 'name1') or session_max_seconds > 'long'`,
+			errContains: `value "long" is not an int`,
+		},
+		{
+			name:        "dangling operator",
+			query:       `name %`,
+			errContains: "missing comparison value",
+		},
+		{
+			name:        "dangling logical operator",
+			query:       `name % 'name1' or`,
+			errContains: "logical operator without a right side expr",
+		},
+	}
+	for _, tc := range invalidQueryCases {
+		t.Run(tc.name, func(t *testing.T) {
+			l, err := r.QueryTargets(ctx, kt1.AuthTokenId, tc.query)
+			assert.Nil(t, l)
+			assert.Truef(t, errors.Match(errors.T(errors.InvalidParameter), err), "unexpected error: %v", err)
+			assert.ErrorContains(t, err, fmt.Sprintf("invalid query %q", tc.query))
+			assert.ErrorContains(t, err, tc.errContains)
+		})
+	}
+
+	ts := []*targets.Target{
+		{
+			Id:                "ttcp_1",
+			Name:              "name1",
+			Address:           "address1",
+			Type:              "tcp",
+			ScopeId:           "p_123",
+			SessionMaxSeconds: 111,
+		},
+		{
+			Id:                "ttcp_2",
+			Name:              "name2",
+			Address:           "address2",
+			Type:              "tcp",
+			ScopeId:           "p_123",
+			SessionMaxSeconds: 222,
+		},
+		{
+			Id:                "ttcp_3",
+			Name:              "name3",
+			Address:           "address3",
+			Type:              "tcp",
+			ScopeId:           "p_123",
+			SessionMaxSeconds: 333,
+		},
+	}
+	_, err = r.refreshTargets(ctx, u1, map[AuthToken]string{{Id: "id"}: "something"},
+		WithTargetRetrievalFunc(testStaticResourceRetrievalFunc(t, [][]*targets.Target{ts}, [][]string{nil})))
+	require.NoError(t, err)
+
+	t.Run("wrong token gets no targets", func(t *testing.T) {
+		l, err := r.QueryTargets(ctx, kt2.AuthTokenId, query)
+		assert.NoError(t, err)
+		assert.Empty(t, l)
+	})
+	t.Run("correct token gets targets", func(t *testing.T) {
+		l, err := r.QueryTargets(ctx, kt1.AuthTokenId, query)
+		assert.NoError(t, err)
+		assert.Len(t, l, 2)
+		assert.ElementsMatch(t, l, ts[0:2])
+	})
+	t.Run("ordered comparison on numeric field", func(t *testing.T) {
+		l, err := r.QueryTargets(ctx, kt1.AuthTokenId, `session_max_seconds >= 222`)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, ts[1:], l)
+	})
+	t.Run("type contains", func(t *testing.T) {
+		l, err := r.QueryTargets(ctx, kt1.AuthTokenId, `type % "tc" and name = "name3"`)
+		assert.NoError(t, err)
+		assert.Equal(t, ts[2:], l)
+	})
+	t.Run("operators in values are not comparisons", func(t *testing.T) {
+		l, err := r.QueryTargets(ctx, kt1.AuthTokenId, `name = "item > 'x'"`)
+		assert.NoError(t, err)
+		assert.Empty(t, l)
+	})
+	t.Run("filtered by scope", func(t *testing.T) {
+		l, err := r.QueryTargets(ctx, kt1.AuthTokenId, `name % 'name'`, WithScopeId("p_123"))
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, ts, l)
+
+		l, err = r.QueryTargets(ctx, kt1.AuthTokenId, `name % 'name'`, WithScopeId("p_456"))
+		assert.NoError(t, err)
+		assert.Empty(t, l)
+	})
+	t.Run("limited", func(t *testing.T) {
+		l, err := r.QueryTargets(ctx, kt1.AuthTokenId, `scope_id = "p_123"`, WithLimit(1))
+		assert.NoError(t, err)
+		assert.Equal(t, []*targets.Target{ts[0]}, l)
+	})
+	t.Run("limited and sorted", func(t *testing.T) {
+		l, err := r.QueryTargets(ctx, kt1.AuthTokenId, `scope_id = "p_123"`, WithLimit(2), WithSort("name", DescendingSortDirection))
+		assert.NoError(t, err)
+		assert.Equal(t, []*targets.Target{ts[2], ts[1]}, l)
+	})
+	t.Run("no limit", func(t *testing.T) {
+		l, err := r.QueryTargets(ctx, kt1.AuthTokenId, `scope_id = "p_123"`, WithLimit(0))
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, ts, l)
+	})
+	t.Run("sorted by session max seconds descending", func(t *testing.T) {
+		l, err := r.QueryTargets(ctx, kt1.AuthTokenId, `scope_id = "p_123"`, WithSort("session_max_seconds", DescendingSortDirection))
+		assert.NoError(t, err)
+		assert.Equal(t, []*targets.Target{ts[2], ts[1], ts[0]}, l)
+	})
+	t.Run("unsortable column", func(t *testing.T) {
+		l, err := r.QueryTargets(ctx, kt1.AuthTokenId, query, WithSort("name; drop table target", AscendingSortDirection))
+		assert.Nil(t, l)
+		assert.Truef(t, errors.Match(errors.T(errors.InvalidParameter), err), "unexpected error: %v", err)
+	})
+}
+
+func TestRepository_SearchTargets(t *testing.T) {
+	ctx := context.Background()
+	s, err := cachedb.Open(ctx)
+	require.NoError(t, err)
+
+	addr := "address"
+	u := &user{
+		Id:      "u1",
+		Address: addr,
+	}
+	at := &authtokens.AuthToken{
+		Id:     "at_1",
+		Token:  "at_1_token",
+		UserId: u.Id,
+	}
+	kt := KeyringToken{KeyringType: "k", TokenName: "t", AuthTokenId: at.Id}
+	atMap := map[ringToken]*authtokens.AuthToken{
+		{kt.KeyringType, kt.TokenName}: at,
+	}
+	r, err := NewRepository(ctx, s, &sync.Map{}, mapBasedAuthTokenKeyringLookup(atMap), sliceBasedAuthTokenBoundaryReader(maps.Values(atMap)))
+	require.NoError(t, err)
+	require.NoError(t, r.AddKeyringToken(ctx, addr, kt))
+
+	frontend := &targets.Target{Id: "ttcp_1", Name: "frontend", Description: "serves the site", Address: "10.0.0.1", Type: "tcp"}
+	database := &targets.Target{Id: "ttcp_2", Name: "db", Description: "primary", Address: "db.internal", Type: "tcp"}
+	uptime := &targets.Target{Id: "ttcp_3", Name: "uptime", Description: "100% available", Address: "10.0.0.3", Type: "tcp"}
+	_, err = r.refreshTargets(ctx, u, map[AuthToken]string{{Id: "id"}: "something"},
+		WithTargetRetrievalFunc(testStaticResourceRetrievalFunc(t, [][]*targets.Target{{frontend, database, uptime}}, [][]string{nil})))
+	require.NoError(t, err)
+
+	t.Run("missing auth token id", func(t *testing.T) {
+		l, err := r.SearchTargets(ctx, "", "front")
+		assert.Nil(t, l)
+		assert.Truef(t, errors.Match(errors.T(errors.InvalidParameter), err), "unexpected error: %v", err)
+	})
+	t.Run("empty text", func(t *testing.T) {
+		l, err := r.SearchTargets(ctx, at.Id, "")
+		assert.Nil(t, l)
+		assert.Truef(t, errors.Match(errors.T(errors.InvalidParameter), err), "unexpected error: %v", err)
+	})
+	t.Run("matches name", func(t *testing.T) {
+		l, err := r.SearchTargets(ctx, at.Id, "FRONT")
+		require.NoError(t, err)
+		assert.Equal(t, []*targets.Target{frontend}, l)
+	})
+	t.Run("matches address", func(t *testing.T) {
+		l, err := r.SearchTargets(ctx, at.Id, "internal")
+		require.NoError(t, err)
+		assert.Equal(t, []*targets.Target{database}, l)
+	})
+	t.Run("matches across fields", func(t *testing.T) {
+		l, err := r.SearchTargets(ctx, at.Id, "10.0.0", WithSort("id", AscendingSortDirection))
+		require.NoError(t, err)
+		assert.Equal(t, []*targets.Target{frontend, uptime}, l)
+	})
+	t.Run("wildcards are escaped", func(t *testing.T) {
+		l, err := r.SearchTargets(ctx, at.Id, "%")
+		require.NoError(t, err)
+		assert.Equal(t, []*targets.Target{uptime}, l)
+
+		l, err = r.SearchTargets(ctx, at.Id, "_")
+		require.NoError(t, err)
+		assert.Empty(t, l)
+	})
+	t.Run("wrong user", func(t *testing.T) {
+		l, err := r.SearchTargets(ctx, "at_unknown", "front")
+		require.NoError(t, err)
+		assert.Empty(t, l)
+	})
+}
+
+func TestRepository_QueryTimeout(t *testing.T) {
+	ctx := context.Background()
+	s, err := cachedb.Open(ctx)
+	require.NoError(t, err)
+
+	addr := "address"
+	u := &user{
+		Id:      "u1",
+		Address: addr,
+	}
+	at := &authtokens.AuthToken{
+		Id:     "at_1",
+		Token:  "at_1_token",
+		UserId: u.Id,
+	}
+	kt := KeyringToken{KeyringType: "k", TokenName: "t", AuthTokenId: at.Id}
+	atMap := map[ringToken]*authtokens.AuthToken{
+		{kt.KeyringType, kt.TokenName}: at,
+	}
+	_, err = NewRepository(ctx, s, &sync.Map{}, mapBasedAuthTokenKeyringLookup(atMap), sliceBasedAuthTokenBoundaryReader(maps.Values(atMap)), WithQueryTimeout(-time.Second))
+	assert.Truef(t, errors.Match(errors.T(errors.InvalidParameter), err), "unexpected error: %v", err)
+
+	const timeout = 50 * time.Millisecond
+	r, err := NewRepository(ctx, s, &sync.Map{}, mapBasedAuthTokenKeyringLookup(atMap), sliceBasedAuthTokenBoundaryReader(maps.Values(atMap)), WithQueryTimeout(timeout))
+	require.NoError(t, err)
+	require.NoError(t, r.AddKeyringToken(ctx, addr, kt))
+
+	// The store only has a single connection, so holding it in a transaction
+	// blocks every other query until the transaction is done.
+	block := func(t *testing.T) {
+		t.Helper()
+		started, release, done := make(chan struct{}), make(chan struct{}), make(chan struct{})
+		go func() {
+			defer close(done)
+			r.rw.DoTx(ctx, db.StdRetryCnt, db.ExpBackoff{}, func(_ db.Reader, _ db.Writer) error {
+				close(started)
+				<-release
+				return nil
+			})
+		}()
+		<-started
+		t.Cleanup(func() {
+			close(release)
+			<-done
+		})
+	}
+
+	t.Run("list", func(t *testing.T) {
+		block(t)
+		start := time.Now()
+		l, err := r.ListTargets(ctx, at.Id)
+		assert.Nil(t, l)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Less(t, time.Since(start), 10*timeout)
+	})
+	t.Run("query", func(t *testing.T) {
+		block(t)
+		l, err := r.QueryTargets(ctx, at.Id, `name % "name"`)
+		assert.Nil(t, l)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+	t.Run("refresh", func(t *testing.T) {
+		block(t)
+		_, err := r.refreshTargets(ctx, u, map[AuthToken]string{{Id: "id"}: "something"},
+			WithTargetRetrievalFunc(testStaticResourceRetrievalFunc(t, [][]*targets.Target{{target("1")}}, [][]string{nil})))
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+	t.Run("caller deadline is kept", func(t *testing.T) {
+		block(t)
+		callerCtx, cancel := context.WithTimeout(ctx, 4*timeout)
+		defer cancel()
+		start := time.Now()
+		_, err := r.ListTargets(callerCtx, at.Id)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.GreaterOrEqual(t, time.Since(start), 4*timeout)
+	})
+	t.Run("unblocked", func(t *testing.T) {
+		_, err = r.refreshTargets(ctx, u, map[AuthToken]string{{Id: "id"}: "something"},
+			WithTargetRetrievalFunc(testStaticResourceRetrievalFunc(t, [][]*targets.Target{{target("1")}}, [][]string{nil})))
+		require.NoError(t, err)
+		l, err := r.ListTargets(ctx, at.Id)
+		require.NoError(t, err)
+		assert.Len(t, l, 1)
+	})
+}
+
+func TestRepository_UpsertAndRemoveTarget(t *testing.T) {
+	ctx := context.Background()
+	s, err := cachedb.Open(ctx)
+	require.NoError(t, err)
+
+	addr := "address"
+	u1 := &user{Id: "u1", Address: addr}
+	at1 := &authtokens.AuthToken{Id: "at_1", Token: "at_1_token", UserId: u1.Id}
+	kt1 := KeyringToken{KeyringType: "k1", TokenName: "t1", AuthTokenId: at1.Id}
+	u2 := &user{Id: "u2", Address: addr}
+	at2 := &authtokens.AuthToken{Id: "at_2", Token: "at_2_token", UserId: u2.Id}
+	kt2 := KeyringToken{KeyringType: "k2", TokenName: "t2", AuthTokenId: at2.Id}
+	atMap := map[ringToken]*authtokens.AuthToken{
+		{"k1", "t1"}: at1,
+		{"k2", "t2"}: at2,
+	}
+	r, err := NewRepository(ctx, s, &sync.Map{}, mapBasedAuthTokenKeyringLookup(atMap), sliceBasedAuthTokenBoundaryReader(maps.Values(atMap)))
+	require.NoError(t, err)
+	require.NoError(t, r.AddKeyringToken(ctx, addr, kt1))
+	require.NoError(t, r.AddKeyringToken(ctx, addr, kt2))
+
+	ts := []*targets.Target{target("1"), target("2"), target("3")}
+	for _, u := range []*user{u1, u2} {
+		_, err = r.refreshTargets(ctx, u, map[AuthToken]string{{Id: "id"}: "something"},
+			WithTargetRetrievalFunc(testStaticResourceRetrievalFunc(t, [][]*targets.Target{ts}, [][]string{nil})))
+		require.NoError(t, err)
+	}
+	list := func(t *testing.T, at *authtokens.AuthToken) []*targets.Target {
+		t.Helper()
+		l, err := r.ListTargets(ctx, at.Id, WithSort("id", AscendingSortDirection))
+		require.NoError(t, err)
+		return l
+	}
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		err := r.UpsertTarget(ctx, nil, target("1"))
+		assert.Truef(t, errors.Match(errors.T(errors.InvalidParameter), err), "unexpected error: %v", err)
+		err = r.UpsertTarget(ctx, u1, nil)
+		assert.Truef(t, errors.Match(errors.T(errors.InvalidParameter), err), "unexpected error: %v", err)
+		err = r.UpsertTarget(ctx, u1, &targets.Target{Address: "addr"})
+		assert.Truef(t, errors.Match(errors.T(errors.InvalidParameter), err), "unexpected error: %v", err)
+		err = r.RemoveTarget(ctx, nil, ts[0].Id)
+		assert.Truef(t, errors.Match(errors.T(errors.InvalidParameter), err), "unexpected error: %v", err)
+		err = r.RemoveTarget(ctx, u1, "")
+		assert.Truef(t, errors.Match(errors.T(errors.InvalidParameter), err), "unexpected error: %v", err)
+	})
+
+	t.Run("upsert changes only that row", func(t *testing.T) {
+		updated := target("2")
+		updated.Name = "updated"
+		require.NoError(t, r.UpsertTarget(ctx, u1, updated))
+		assert.Equal(t, []*targets.Target{ts[0], updated, ts[2]}, list(t, at1))
+		assert.Equal(t, ts, list(t, at2))
+
+		added := target("4")
+		require.NoError(t, r.UpsertTarget(ctx, u1, added))
+		assert.Equal(t, []*targets.Target{ts[0], updated, ts[2], added}, list(t, at1))
+		assert.Equal(t, ts, list(t, at2))
+	})
+
+	t.Run("remove deletes only that row", func(t *testing.T) {
+		require.NoError(t, r.RemoveTarget(ctx, u1, ts[0].Id))
+		got := list(t, at1)
+		require.Len(t, got, 3)
+		assert.NotContains(t, []string{got[0].Id, got[1].Id, got[2].Id}, ts[0].Id)
+		assert.Equal(t, ts, list(t, at2))
+
+		// Removing a target which isn't cached is not an error.
+		require.NoError(t, r.RemoveTarget(ctx, u1, ts[0].Id))
+		assert.Len(t, list(t, at1), 3)
+	})
+}
+
+func TestRepository_DistinctTargetAddresses(t *testing.T) {
+	ctx := context.Background()
+	s, err := cachedb.Open(ctx)
+	require.NoError(t, err)
+
+	addr := "address"
+	u1 := &user{Id: "u1", Address: addr}
+	at1 := &authtokens.AuthToken{Id: "at_1", Token: "at_1_token", UserId: u1.Id}
+	kt1 := KeyringToken{KeyringType: "k1", TokenName: "t1", AuthTokenId: at1.Id}
+	u2 := &user{Id: "u2", Address: addr}
+	at2 := &authtokens.AuthToken{Id: "at_2", Token: "at_2_token", UserId: u2.Id}
+	kt2 := KeyringToken{KeyringType: "k2", TokenName: "t2", AuthTokenId: at2.Id}
+	atMap := map[ringToken]*authtokens.AuthToken{
+		{"k1", "t1"}: at1,
+		{"k2", "t2"}: at2,
+	}
+	r, err := NewRepository(ctx, s, &sync.Map{}, mapBasedAuthTokenKeyringLookup(atMap), sliceBasedAuthTokenBoundaryReader(maps.Values(atMap)))
+	require.NoError(t, err)
+	require.NoError(t, r.AddKeyringToken(ctx, addr, kt1))
+	require.NoError(t, r.AddKeyringToken(ctx, addr, kt2))
+
+	u1Targets := []*targets.Target{
+		{Id: "ttcp_1", Address: "db.internal", Type: "tcp"},
+		{Id: "ttcp_2", Address: "DB.internal ", Type: "tcp"},
+		{Id: "ttcp_3", Address: "10.0.0.1", Type: "tcp"},
+		{Id: "ttcp_4", Address: "10.0.0.1", Type: "tcp"},
+		{Id: "ttcp_5", HostSourceIds: []string{"hsst_1"}, Type: "tcp"},
+	}
+	_, err = r.refreshTargets(ctx, u1, map[AuthToken]string{{Id: "id"}: "something"},
+		WithTargetRetrievalFunc(testStaticResourceRetrievalFunc(t, [][]*targets.Target{u1Targets}, [][]string{nil})))
+	require.NoError(t, err)
+	_, err = r.refreshTargets(ctx, u2, map[AuthToken]string{{Id: "id"}: "something"},
+		WithTargetRetrievalFunc(testStaticResourceRetrievalFunc(t, [][]*targets.Target{{{Id: "ttcp_6", Address: "other.internal", Type: "tcp"}}}, [][]string{nil})))
+	require.NoError(t, err)
+
+	t.Run("missing auth token id", func(t *testing.T) {
+		got, err := r.DistinctTargetAddresses(ctx, "")
+		assert.Nil(t, got)
+		assert.Truef(t, errors.Match(errors.T(errors.InvalidParameter), err), "unexpected error: %v", err)
+	})
+	t.Run("deduplicated", func(t *testing.T) {
+		got, err := r.DistinctTargetAddresses(ctx, at1.Id)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"10.0.0.1", "db.internal"}, got)
+	})
+	t.Run("other user", func(t *testing.T) {
+		got, err := r.DistinctTargetAddresses(ctx, at2.Id)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"other.internal"}, got)
+	})
+	t.Run("unknown token", func(t *testing.T) {
+		got, err := r.DistinctTargetAddresses(ctx, "at_unknown")
+		require.NoError(t, err)
+		assert.Empty(t, got)
+	})
+}
+
+func TestRepository_DeleteTargetsWhere(t *testing.T) {
+	ctx := context.Background()
+	s, err := cachedb.Open(ctx)
+	require.NoError(t, err)
+
+	addr := "address"
+	u1 := &user{Id: "u1", Address: addr}
+	at1 := &authtokens.AuthToken{Id: "at_1", Token: "at_1_token", UserId: u1.Id}
+	kt1 := KeyringToken{KeyringType: "k1", TokenName: "t1", AuthTokenId: at1.Id}
+	u2 := &user{Id: "u2", Address: addr}
+	at2 := &authtokens.AuthToken{Id: "at_2", Token: "at_2_token", UserId: u2.Id}
+	kt2 := KeyringToken{KeyringType: "k2", TokenName: "t2", AuthTokenId: at2.Id}
+	atMap := map[ringToken]*authtokens.AuthToken{
+		{"k1", "t1"}: at1,
+		{"k2", "t2"}: at2,
+	}
+	r, err := NewRepository(ctx, s, &sync.Map{}, mapBasedAuthTokenKeyringLookup(atMap), sliceBasedAuthTokenBoundaryReader(maps.Values(atMap)))
+	require.NoError(t, err)
+	require.NoError(t, r.AddKeyringToken(ctx, addr, kt1))
+	require.NoError(t, r.AddKeyringToken(ctx, addr, kt2))
+
+	ts := []*targets.Target{target("1"), target("2"), target("3")}
+	ts[1].ScopeId = ts[0].ScopeId
+	for _, u := range []*user{u1, u2} {
+		_, err = r.refreshTargets(ctx, u, map[AuthToken]string{{Id: "id"}: "something"},
+			WithTargetRetrievalFunc(testStaticResourceRetrievalFunc(t, [][]*targets.Target{ts}, [][]string{nil})))
+		require.NoError(t, err)
+	}
+
+	errorCases := []struct {
+		name        string
+		authTokenId string
+		query       string
+		errContains string
+	}{
+		{
+			name:        "auth token id is missing",
+			query:       `scope_id = "p_1"`,
+			errContains: "auth token id is missing",
+		},
+		{
+			name:        "query is missing",
+			authTokenId: at1.Id,
+			errContains: "query is missing",
+		},
+		{
+			name:        "query is blank",
+			authTokenId: at1.Id,
+			query:       "  ",
+			errContains: "query is missing",
+		},
+		{
+			name:        "invalid query",
+			authTokenId: at1.Id,
+			query:       `nickname % 'name1'`,
+			errContains: `invalid column "nickname"`,
+		},
+		{
+			name:        "field which can't be queried",
+			authTokenId: at1.Id,
+			query:       `item % 'name1'`,
+			errContains: `invalid column "item"`,
+		},
+		{
+			name:        "value of the wrong type",
+			authTokenId: at1.Id,
+			query:       `scope_id = 'p_1' and session_max_seconds < 'long'`,
+			errContains: `value "long" is not an int`,
+		},
+	}
+	for _, tc := range errorCases {
+		t.Run(tc.name, func(t *testing.T) {
+			n, err := r.DeleteTargetsWhere(ctx, tc.authTokenId, tc.query)
+			assert.Zero(t, n)
+			assert.Truef(t, errors.Match(errors.T(errors.InvalidParameter), err), "unexpected error: %v", err)
+			assert.ErrorContains(t, err, tc.errContains)
+		})
+	}
+
+	t.Run("deletes matching targets", func(t *testing.T) {
+		n, err := r.DeleteTargetsWhere(ctx, at1.Id, `scope_id = "p_1"`)
+		require.NoError(t, err)
+		assert.Equal(t, 2, n)
+
+		got, err := r.ListTargets(ctx, at1.Id)
+		require.NoError(t, err)
+		assert.Equal(t, []*targets.Target{ts[2]}, got)
+
+		// The other user's targets are left untouched.
+		got, err = r.ListTargets(ctx, at2.Id)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, ts, got)
+	})
+
+	t.Run("no matches", func(t *testing.T) {
+		n, err := r.DeleteTargetsWhere(ctx, at1.Id, `scope_id = "p_1"`)
+		require.NoError(t, err)
+		assert.Zero(t, n)
+	})
+}
+
+func TestDefaultTargetRetrievalFunc(t *testing.T) {
+	oldDur := globals.RefreshReadLookbackDuration
+	globals.RefreshReadLookbackDuration = 0
+	t.Cleanup(func() {
+		globals.RefreshReadLookbackDuration = oldDur
+	})
+
+	tc := controller.NewTestController(t, nil)
+	tc.Client().SetToken(tc.Token().Token)
+	tarClient := targets.NewClient(tc.Client())
+
+	tar1, err := tarClient.Create(tc.Context(), "tcp", "p_1234567890", targets.WithName("tar1"), targets.WithTcpTargetDefaultPort(1))
+	require.NoError(t, err)
+	require.NotNil(t, tar1)
+	tar2, err := tarClient.Create(tc.Context(), "tcp", "p_1234567890", targets.WithName("tar2"), targets.WithTcpTargetDefaultPort(2))
+	require.NoError(t, err)
+	require.NotNil(t, tar2)
+
+	got, removed, refTok, err := defaultTargetFunc(tc.Context(), tc.ApiAddrs()[0], tc.Token().Token, "")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, refTok)
+	assert.Empty(t, removed)
+	found1 := false
 	found2 := false
 	for _, t := range got {
 		if t.Id == tar1.Item.Id {
@@ -508,3 +1716,356 @@ func TestDefaultTargetRetrievalFunc(t *testing.T) {
 	assert.Empty(t, removed2)
 	assert.Empty(t, got2)
 }
+
+// testEventEmitter is an EventEmitter which records the events it receives.
+type testEventEmitter struct {
+	refreshed []*TargetsRefreshedEvent
+	stale     []*StaleTargetsListedEvent
+}
+
+func (e *testEventEmitter) TargetsRefreshed(_ context.Context, ev *TargetsRefreshedEvent) {
+	e.refreshed = append(e.refreshed, ev)
+}
+
+func (e *testEventEmitter) StaleTargetsListed(_ context.Context, ev *StaleTargetsListedEvent) {
+	e.stale = append(e.stale, ev)
+}
+
+func TestRepository_TargetEvents(t *testing.T) {
+	ctx := context.Background()
+	s, err := cachedb.Open(ctx)
+	require.NoError(t, err)
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	addr := "address"
+	u := &user{
+		Id:      "u1",
+		Address: addr,
+	}
+	at := &authtokens.AuthToken{
+		Id:     "at_1",
+		Token:  "at_1_token",
+		UserId: u.Id,
+	}
+	kt := KeyringToken{KeyringType: "k", TokenName: "t", AuthTokenId: at.Id}
+	atMap := map[ringToken]*authtokens.AuthToken{
+		{kt.KeyringType, kt.TokenName}: at,
+	}
+	emitter := &testEventEmitter{}
+	r, err := NewRepository(ctx, s, &sync.Map{}, mapBasedAuthTokenKeyringLookup(atMap), sliceBasedAuthTokenBoundaryReader(maps.Values(atMap)),
+		withClock(clock), WithEventEmitter(emitter), WithStaleThreshold(time.Hour))
+	require.NoError(t, err)
+	require.NoError(t, r.AddKeyringToken(ctx, addr, kt))
+
+	retFn := testStaticResourceRetrievalFunc(t,
+		[][]*targets.Target{
+			{target("1"), target("2"), target("3")},
+			{target("1"), target("4"), target("5")},
+		},
+		[][]string{
+			nil,
+			{target("2").Id, target("3").Id},
+		})
+
+	counts, err := r.refreshTargets(ctx, u, map[AuthToken]string{{Id: "id"}: "something"}, WithTargetRetrievalFunc(retFn))
+	require.NoError(t, err)
+	assert.Equal(t, RefreshCounts{Added: 3}, counts)
+	// The unchanged target 1 in the delta is neither added nor updated.
+	counts, err = r.refreshTargets(ctx, u, map[AuthToken]string{{Id: "id"}: "something"}, WithTargetRetrievalFunc(retFn))
+	require.NoError(t, err)
+	assert.Equal(t, RefreshCounts{Added: 2, Removed: 2}, counts)
+	assert.Equal(t, []*TargetsRefreshedEvent{
+		{UserId: u.Id, Added: 3, Removed: 0},
+		{UserId: u.Id, Added: 2, Removed: 2},
+	}, emitter.refreshed)
+
+	t.Run("full refresh", func(t *testing.T) {
+		// Without a refresh token every cached target is replaced.
+		emitter.refreshed = nil
+		require.NoError(t, r.deleteRefreshToken(ctx, u, targetResourceType))
+		counts, err := r.refreshTargets(ctx, u, map[AuthToken]string{{Id: "id"}: "something"}, WithTargetRetrievalFunc(retFn))
+		require.NoError(t, err)
+		assert.Equal(t, RefreshCounts{Added: 3, Removed: 3}, counts)
+		assert.Equal(t, []*TargetsRefreshedEvent{{UserId: u.Id, Added: 3, Removed: 3}}, emitter.refreshed)
+	})
+
+	t.Run("fresh", func(t *testing.T) {
+		_, err := r.ListTargets(ctx, at.Id)
+		require.NoError(t, err)
+		assert.Empty(t, emitter.stale)
+	})
+	t.Run("stale", func(t *testing.T) {
+		refreshed := now
+		now = now.Add(2 * time.Hour)
+		_, err := r.ListTargets(ctx, at.Id)
+		require.NoError(t, err)
+		require.Len(t, emitter.stale, 1)
+		assert.Equal(t, u.Id, emitter.stale[0].UserId)
+		assert.True(t, refreshed.Equal(emitter.stale[0].LastRefreshTime))
+		assert.Equal(t, 2*time.Hour, emitter.stale[0].Age)
+	})
+}
+
+func TestNewRepository_NoEventEmitter(t *testing.T) {
+	ctx := context.Background()
+	s, err := cachedb.Open(ctx)
+	require.NoError(t, err)
+
+	u := &user{
+		Id:      "u1",
+		Address: "address",
+	}
+	r, err := NewRepository(ctx, s, &sync.Map{}, mapBasedAuthTokenKeyringLookup(nil), sliceBasedAuthTokenBoundaryReader(nil))
+	require.NoError(t, err)
+	assert.Equal(t, noopEventEmitter{}, r.emitter)
+	assert.Equal(t, noopMetrics{}, r.metrics)
+	require.NoError(t, r.rw.Create(ctx, u))
+	_, err = r.refreshTargets(ctx, u, map[AuthToken]string{{Id: "id"}: "something"},
+		WithTargetRetrievalFunc(testStaticResourceRetrievalFunc(t, [][]*targets.Target{{target("1")}}, [][]string{nil})))
+	require.NoError(t, err)
+
+	_, err = NewRepository(ctx, s, &sync.Map{}, mapBasedAuthTokenKeyringLookup(nil), sliceBasedAuthTokenBoundaryReader(nil),
+		WithStaleThreshold(-time.Second))
+	assert.True(t, errors.Match(errors.T(errors.InvalidParameter), err))
+}
+
+type testQueryObservation struct {
+	typ resource.Type
+	op  string
+	err error
+}
+
+type testMetrics struct {
+	observed []testQueryObservation
+}
+
+func (m *testMetrics) ObserveQuery(typ resource.Type, op string, d time.Duration, err error) {
+	m.observed = append(m.observed, testQueryObservation{typ: typ, op: op, err: err})
+}
+
+func TestRepository_TargetMetrics(t *testing.T) {
+	ctx := context.Background()
+	s, err := cachedb.Open(ctx)
+	require.NoError(t, err)
+
+	addr := "address"
+	u := &user{Id: "u1", Address: addr}
+	at := &authtokens.AuthToken{Id: "at_1", Token: "at_1_token", UserId: u.Id}
+	kt := KeyringToken{KeyringType: "k", TokenName: "t", AuthTokenId: at.Id}
+	atMap := map[ringToken]*authtokens.AuthToken{
+		{kt.KeyringType, kt.TokenName}: at,
+	}
+	m := &testMetrics{}
+	r, err := NewRepository(ctx, s, &sync.Map{}, mapBasedAuthTokenKeyringLookup(atMap), sliceBasedAuthTokenBoundaryReader(maps.Values(atMap)),
+		WithMetrics(m))
+	require.NoError(t, err)
+	require.NoError(t, r.AddKeyringToken(ctx, addr, kt))
+
+	_, err = r.refreshTargets(ctx, u, map[AuthToken]string{{Id: "id"}: "something"},
+		WithTargetRetrievalFunc(testStaticResourceRetrievalFunc(t, [][]*targets.Target{{target("1")}}, [][]string{nil})))
+	require.NoError(t, err)
+	_, err = r.ListTargets(ctx, at.Id)
+	require.NoError(t, err)
+	_, err = r.QueryTargets(ctx, at.Id, `nickname % 'name1'`)
+	require.Error(t, err)
+
+	require.Len(t, m.observed, 3)
+	assert.Equal(t, testQueryObservation{typ: resource.Target, op: "refresh"}, m.observed[0])
+	assert.Equal(t, testQueryObservation{typ: resource.Target, op: "list"}, m.observed[1])
+	assert.Equal(t, resource.Target, m.observed[2].typ)
+	assert.Equal(t, "query", m.observed[2].op)
+	assert.Equal(t, err, m.observed[2].err)
+}
+
+func TestRepository_RefreshTargets_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	s, err := cachedb.Open(ctx)
+	require.NoError(t, err)
+
+	addr := "address"
+	u := &user{
+		Id:      "u1",
+		Address: addr,
+	}
+	at := &authtokens.AuthToken{
+		Id:     "at_1",
+		Token:  "at_1_token",
+		UserId: u.Id,
+	}
+	kt := KeyringToken{KeyringType: "k", TokenName: "t", AuthTokenId: at.Id}
+	atMap := map[ringToken]*authtokens.AuthToken{
+		{kt.KeyringType, kt.TokenName}: at,
+	}
+	r, err := NewRepository(ctx, s, &sync.Map{}, mapBasedAuthTokenKeyringLookup(atMap), sliceBasedAuthTokenBoundaryReader(maps.Values(atMap)))
+	require.NoError(t, err)
+	require.NoError(t, r.AddKeyringToken(ctx, addr, kt))
+
+	// Every refresh after the first gets an invalid list token error and so
+	// replaces all of the user's cached targets with its own set.
+	const numSets = 10
+	sets := make([][]*targets.Target, numSets)
+	for i := range sets {
+		for j := 0; j < 5; j++ {
+			sets[i] = append(sets[i], target(fmt.Sprintf("%d_%d", i, j)))
+		}
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, numSets)
+	for i := range sets {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = r.refreshTargets(ctx, u, map[AuthToken]string{{Id: "id"}: "something"},
+				WithTargetRetrievalFunc(testErroringForRefreshTokenRetrievalFunc(t, sets[i])))
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+
+	got, err := r.ListTargets(ctx, at.Id, WithSort("id", AscendingSortDirection))
+	require.NoError(t, err)
+	require.Len(t, got, 5)
+	var matched bool
+	for _, set := range sets {
+		if got[0].Id == set[0].Id {
+			assert.Equal(t, set, got)
+			matched = true
+		}
+	}
+	assert.True(t, matched, "cached targets are not one complete set: %v", got)
+}
+
+func TestRepository_RefreshTargets_Incremental(t *testing.T) {
+	ctx := context.Background()
+	s, err := cachedb.Open(ctx)
+	require.NoError(t, err)
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	addr := "address"
+	u := &user{
+		Id:      "u1",
+		Address: addr,
+	}
+	at := &authtokens.AuthToken{
+		Id:     "at_1",
+		Token:  "at_1_token",
+		UserId: u.Id,
+	}
+	kt := KeyringToken{KeyringType: "k", TokenName: "t", AuthTokenId: at.Id}
+	atMap := map[ringToken]*authtokens.AuthToken{
+		{kt.KeyringType, kt.TokenName}: at,
+	}
+	emitter := &testEventEmitter{}
+	r, err := NewRepository(ctx, s, &sync.Map{}, mapBasedAuthTokenKeyringLookup(atMap), sliceBasedAuthTokenBoundaryReader(maps.Values(atMap)),
+		withClock(clock), WithEventEmitter(emitter))
+	require.NoError(t, err)
+	require.NoError(t, r.AddKeyringToken(ctx, addr, kt))
+
+	// Every refresh gets the full set of targets, since a refresh token
+	// results in an invalid list token error.
+	var current []*targets.Target
+	retFn := func(_ context.Context, _, _ string, refTok RefreshTokenValue) ([]*targets.Target, []string, RefreshTokenValue, error) {
+		if refTok != "" {
+			return nil, nil, "", api.ErrInvalidListToken
+		}
+		return current, nil, "1", nil
+	}
+	refresh := func(t *testing.T) RefreshCounts {
+		t.Helper()
+		emitter.refreshed = nil
+		counts, err := r.refreshTargets(ctx, u, map[AuthToken]string{{Id: "id"}: "something"},
+			WithTargetRetrievalFunc(retFn), WithIncrementalRefresh(true))
+		require.NoError(t, err)
+		require.Len(t, emitter.refreshed, 1)
+		assert.Equal(t, &TargetsRefreshedEvent{UserId: u.Id, Added: counts.Added, Updated: counts.Updated, Removed: counts.Removed}, emitter.refreshed[0])
+		return counts
+	}
+	// targetWrites returns the number of cached target rows which have been
+	// inserted, updated or deleted since the previous call.
+	_, err = r.rw.Exec(ctx, `
+create temp table target_writes (n integer);
+create temp trigger target_inserts after insert on main.target begin insert into target_writes values (1); end;
+create temp trigger target_updates after update on main.target begin insert into target_writes values (1); end;
+create temp trigger target_deletes after delete on main.target begin insert into target_writes values (1); end;
+`, nil)
+	require.NoError(t, err)
+	targetWrites := func(t *testing.T) int {
+		t.Helper()
+		rows, err := r.rw.Query(ctx, "select count(*) from target_writes", nil)
+		require.NoError(t, err)
+		var n int
+		require.True(t, rows.Next())
+		require.NoError(t, rows.Scan(&n))
+		require.NoError(t, rows.Close())
+		_, err = r.rw.Exec(ctx, "delete from target_writes", nil)
+		require.NoError(t, err)
+		return n
+	}
+	firstSeen := func(t *testing.T) map[string]time.Time {
+		t.Helper()
+		var got []*Target
+		require.NoError(t, r.rw.SearchWhere(ctx, &got, "fk_user_id = ?", []any{u.Id}))
+		ret := make(map[string]time.Time, len(got))
+		for _, g := range got {
+			ret[g.Id] = g.FirstSeenTime.UTC()
+		}
+		return ret
+	}
+
+	t1, t2, t3 := target("1"), target("2"), target("3")
+	current = []*targets.Target{t1, t2, t3}
+	initial := now
+	assert.Equal(t, RefreshCounts{Added: 3}, refresh(t))
+	assert.Equal(t, 3, targetWrites(t))
+
+	t.Run("no change", func(t *testing.T) {
+		now = now.Add(time.Hour)
+		assert.Equal(t, RefreshCounts{}, refresh(t))
+		assert.Zero(t, targetWrites(t))
+		assert.Equal(t, map[string]time.Time{
+			t1.Id: initial,
+			t2.Id: initial,
+			t3.Id: initial,
+		}, firstSeen(t))
+	})
+	t.Run("added updated and removed", func(t *testing.T) {
+		now = now.Add(time.Hour)
+		updated := target("2")
+		updated.Name = "a different name"
+		t4 := target("4")
+		current = []*targets.Target{t1, updated, t4}
+		assert.Equal(t, RefreshCounts{Added: 1, Updated: 1, Removed: 1}, refresh(t))
+		assert.Equal(t, 3, targetWrites(t))
+		assert.Equal(t, map[string]time.Time{
+			t1.Id: initial,
+			t2.Id: initial,
+			t4.Id: now,
+		}, firstSeen(t))
+
+		got, err := r.ListTargets(ctx, at.Id)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, current, got)
+	})
+	t.Run("without an event emitter", func(t *testing.T) {
+		// The changes aren't reported, but they still need to be applied.
+		r, err := NewRepository(ctx, s, &sync.Map{}, mapBasedAuthTokenKeyringLookup(atMap), sliceBasedAuthTokenBoundaryReader(maps.Values(atMap)),
+			withClock(clock))
+		require.NoError(t, err)
+		current = []*targets.Target{t1}
+		_, err = r.refreshTargets(ctx, u, map[AuthToken]string{{Id: "id"}: "something"},
+			WithTargetRetrievalFunc(retFn), WithIncrementalRefresh(true))
+		require.NoError(t, err)
+
+		got, err := r.ListTargets(ctx, at.Id)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, current, got)
+		assert.Equal(t, map[string]time.Time{t1.Id: initial}, firstSeen(t))
+	})
+}
@@ -5,13 +5,19 @@ package cache
 
 import (
 	"context"
+	"database/sql"
 	stdErrors "errors"
 	"fmt"
+	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/boundary/api/authtokens"
+	"github.com/hashicorp/boundary/api/targets"
 	cachedb "github.com/hashicorp/boundary/internal/clientcache/internal/db"
+	"github.com/hashicorp/boundary/internal/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -123,3 +129,219 @@ func TestRepository_lookupError(t *testing.T) {
 		assert.NotNil(t, got)
 	})
 }
+
+func TestRepository_Vacuum(t *testing.T) {
+	ctx := context.Background()
+	s, err := cachedb.Open(ctx)
+	require.NoError(t, err)
+
+	addr := "address"
+	u := &user{
+		Id:      "u1",
+		Address: addr,
+	}
+	at := &authtokens.AuthToken{
+		Id:             "at_1",
+		Token:          "at_1_token",
+		UserId:         u.Id,
+		ExpirationTime: time.Now().Add(time.Hour),
+	}
+	kt := KeyringToken{KeyringType: "k", TokenName: "t", AuthTokenId: at.Id}
+	atMap := map[ringToken]*authtokens.AuthToken{
+		{kt.KeyringType, kt.TokenName}: at,
+	}
+	r, err := NewRepository(ctx, s, &sync.Map{}, mapBasedAuthTokenKeyringLookup(atMap), sliceBasedAuthTokenBoundaryReader([]*authtokens.AuthToken{at}))
+	require.NoError(t, err)
+	require.NoError(t, r.AddKeyringToken(ctx, addr, kt))
+
+	pageCount := func(t *testing.T) int {
+		t.Helper()
+		rows, err := r.rw.Query(ctx, "pragma page_count", nil)
+		require.NoError(t, err)
+		defer rows.Close()
+		require.True(t, rows.Next())
+		var count int
+		require.NoError(t, rows.Scan(&count))
+		return count
+	}
+
+	var ts []*targets.Target
+	for i := 0; i < 2000; i++ {
+		tar := target(fmt.Sprintf("%d", i))
+		tar.Description = strings.Repeat("d", 500)
+		ts = append(ts, tar)
+	}
+	_, err = r.refreshTargets(ctx, u, map[AuthToken]string{{Id: "id"}: "something"},
+		WithTargetRetrievalFunc(testStaticResourceRetrievalFunc(t, [][]*targets.Target{ts}, [][]string{nil})))
+	require.NoError(t, err)
+	_, err = r.rw.Exec(ctx, "delete from target where id != @id", []any{sql.Named("id", ts[0].Id)})
+	require.NoError(t, err)
+
+	before := pageCount(t)
+	require.NoError(t, r.Vacuum(ctx))
+	assert.Less(t, pageCount(t), before)
+
+	got, err := r.ListTargets(ctx, at.Id)
+	require.NoError(t, err)
+	assert.Equal(t, ts[:1], got)
+
+	t.Run("remove orphaned users", func(t *testing.T) {
+		orphaned := &user{
+			Id:      "u2",
+			Address: addr,
+		}
+		require.NoError(t, r.rw.Create(ctx, orphaned))
+		require.NoError(t, r.rw.Create(ctx, &AuthToken{Id: "at_2", UserId: orphaned.Id}))
+
+		require.NoError(t, r.Vacuum(ctx))
+		us, err := r.listUsers(ctx)
+		require.NoError(t, err)
+		assert.Len(t, us, 2)
+
+		require.NoError(t, r.Vacuum(ctx, WithRemoveOrphanedUsers(true)))
+		us, err = r.listUsers(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []*user{u}, us)
+
+		got, err := r.ListTargets(ctx, at.Id)
+		require.NoError(t, err)
+		assert.Equal(t, ts[:1], got)
+	})
+}
+
+func TestRepository_MarkClosed(t *testing.T) {
+	ctx := context.Background()
+	url := fmt.Sprintf("file:%s?_pragma=foreign_keys(1)", filepath.Join(t.TempDir(), "cache.db"))
+	s, err := cachedb.Open(ctx, cachedb.WithUrl(url))
+	require.NoError(t, err)
+
+	addr := "address"
+	at := &authtokens.AuthToken{
+		Id:             "at_1",
+		Token:          "at_1_token",
+		UserId:         "u_1",
+		ExpirationTime: time.Now().Add(time.Hour),
+	}
+	kt := KeyringToken{KeyringType: "k", TokenName: "t", AuthTokenId: at.Id}
+	atMap := map[ringToken]*authtokens.AuthToken{
+		{kt.KeyringType, kt.TokenName}: at,
+	}
+	r, err := NewRepository(ctx, s, &sync.Map{}, mapBasedAuthTokenKeyringLookup(atMap), sliceBasedAuthTokenBoundaryReader([]*authtokens.AuthToken{at}))
+	require.NoError(t, err)
+	require.NoError(t, r.AddKeyringToken(ctx, addr, kt))
+	other, err := NewRepository(ctx, s, &sync.Map{}, mapBasedAuthTokenKeyringLookup(atMap), sliceBasedAuthTokenBoundaryReader([]*authtokens.AuthToken{at}))
+	require.NoError(t, err)
+
+	r.MarkClosed()
+	// Marking it closed again is a no-op.
+	r.MarkClosed()
+
+	_, err = r.ListTargets(ctx, at.Id)
+	assert.Truef(t, errors.Match(errors.T(errors.Closed), err), "unexpected error: %v", err)
+	assert.ErrorContains(t, err, "store is closed")
+	_, err = r.LookupToken(ctx, at.Id)
+	assert.Truef(t, errors.Match(errors.T(errors.Closed), err), "unexpected error: %v", err)
+	err = r.AddKeyringToken(ctx, addr, kt)
+	assert.Truef(t, errors.Match(errors.T(errors.Closed), err), "unexpected error: %v", err)
+	_, err = r.refreshTargets(ctx, &user{Id: at.UserId, Address: addr}, map[AuthToken]string{{Id: at.Id}: at.Token},
+		WithTargetRetrievalFunc(testStaticResourceRetrievalFunc(t, [][]*targets.Target{{target("1")}}, [][]string{nil})))
+	assert.Truef(t, errors.Match(errors.T(errors.Closed), err), "unexpected error: %v", err)
+
+	// Other repositories using the same store are unaffected.
+	got, err := other.LookupToken(ctx, at.Id)
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	other.MarkClosed()
+	require.NoError(t, cachedb.Close(ctx, s))
+
+	// The same store can be opened again and still has the cached data.
+	s, err = cachedb.Open(ctx, cachedb.WithUrl(url))
+	require.NoError(t, err)
+	r, err = NewRepository(ctx, s, &sync.Map{}, mapBasedAuthTokenKeyringLookup(atMap), sliceBasedAuthTokenBoundaryReader([]*authtokens.AuthToken{at}))
+	require.NoError(t, err)
+	t.Cleanup(func() { cachedb.Close(ctx, s) })
+	got, err = r.LookupToken(ctx, at.Id)
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, at.UserId, got.UserId)
+}
+
+func TestRepository_Ping(t *testing.T) {
+	ctx := context.Background()
+	s, err := cachedb.Open(ctx)
+	require.NoError(t, err)
+
+	r, err := NewRepository(ctx, s, &sync.Map{}, mapBasedAuthTokenKeyringLookup(nil), sliceBasedAuthTokenBoundaryReader(nil))
+	require.NoError(t, err)
+
+	t.Run("healthy", func(t *testing.T) {
+		assert.NoError(t, r.Ping(ctx))
+	})
+	t.Run("read only", func(t *testing.T) {
+		_, err := r.rw.Exec(ctx, "pragma query_only = true", nil)
+		require.NoError(t, err)
+		err = r.Ping(ctx)
+		assert.ErrorContains(t, err, "store is not writable")
+
+		_, err = r.rw.Exec(ctx, "pragma query_only = false", nil)
+		require.NoError(t, err)
+		assert.NoError(t, r.Ping(ctx))
+	})
+	t.Run("closed", func(t *testing.T) {
+		r.MarkClosed()
+		err := r.Ping(ctx)
+		assert.Truef(t, errors.Match(errors.T(errors.Closed), err), "unexpected error: %v", err)
+	})
+}
+
+func TestRepository_lockUser(t *testing.T) {
+	ctx := context.Background()
+	s, err := cachedb.Open(ctx)
+	require.NoError(t, err)
+	r, err := NewRepository(ctx, s, &sync.Map{}, mapBasedAuthTokenKeyringLookup(nil), sliceBasedAuthTokenBoundaryReader(nil))
+	require.NoError(t, err)
+
+	// lockUserAsync reports on the returned channel once it holds the lock of
+	// the provided user.
+	lockUserAsync := func(id string) <-chan func() {
+		locked := make(chan func(), 1)
+		go func() { locked <- r.lockUser(id) }()
+		return locked
+	}
+
+	t.Run("same user", func(t *testing.T) {
+		unlock := r.lockUser("u1")
+		locked := lockUserAsync("u1")
+		select {
+		case <-locked:
+			t.Fatal("the same user was locked twice")
+		case <-time.After(50 * time.Millisecond):
+		}
+		unlock()
+		select {
+		case unlock := <-locked:
+			unlock()
+		case <-time.After(5 * time.Second):
+			t.Fatal("the user was never locked after being unlocked")
+		}
+	})
+	t.Run("distinct users don't block each other", func(t *testing.T) {
+		var unlocks []func()
+		for i := 0; i < 1000; i++ {
+			select {
+			case unlock := <-lockUserAsync(fmt.Sprintf("u%d", i)):
+				unlocks = append(unlocks, unlock)
+			case <-time.After(5 * time.Second):
+				t.Fatalf("locking user u%d blocked on another user", i)
+			}
+		}
+		for _, unlock := range unlocks {
+			unlock()
+		}
+	})
+	t.Run("released locks are dropped", func(t *testing.T) {
+		r.userLocksMu.Lock()
+		defer r.userLocksMu.Unlock()
+		assert.Empty(t, r.userLocks)
+	})
+}
@@ -6,14 +6,17 @@ package cache
 import (
 	"context"
 	"database/sql"
+	stderrors "errors"
 	"fmt"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/hashicorp/boundary/api"
 	"github.com/hashicorp/boundary/api/authtokens"
 	"github.com/hashicorp/boundary/internal/db"
 	"github.com/hashicorp/boundary/internal/errors"
+	"github.com/hashicorp/boundary/internal/event"
 	"github.com/hashicorp/boundary/internal/util"
 )
 
@@ -110,6 +113,9 @@ func upsertUserAndAuthToken(ctx context.Context, reader db.Reader, writer db.Wri
 // has permission to send a Read request for itself to boundary.
 func (r *Repository) AddRawToken(ctx context.Context, bAddr string, rawToken string) error {
 	const op = "cache.(Repository).AddRawToken"
+	if err := r.checkOpen(ctx, op); err != nil {
+		return err
+	}
 	switch {
 	case rawToken == "":
 		return errors.New(ctx, errors.InvalidParameter, op, "boundary auth token is empty", errors.WithoutEvent())
@@ -184,6 +190,9 @@ func (r *Repository) AddRawToken(ctx context.Context, bAddr string, rawToken str
 // stored in the keyring must also match the user id returned from boundary.
 func (r *Repository) AddKeyringToken(ctx context.Context, bAddr string, token KeyringToken) error {
 	const op = "cache.(Repository).AddKeyringToken"
+	if err := r.checkOpen(ctx, op); err != nil {
+		return err
+	}
 	switch {
 	case token.TokenName == "":
 		return errors.New(ctx, errors.InvalidParameter, op, "token name is empty", errors.WithoutEvent())
@@ -250,6 +259,9 @@ func (r *Repository) AddKeyringToken(ctx context.Context, bAddr string, token Ke
 // have the updated time.
 func (r *Repository) LookupToken(ctx context.Context, authTokenId string, opt ...Option) (*AuthToken, error) {
 	const op = "cache.(Repository).LookupToken"
+	if err := r.checkOpen(ctx, op); err != nil {
+		return nil, err
+	}
 	switch {
 	case authTokenId == "":
 		return nil, errors.New(ctx, errors.InvalidParameter, op, "auth token id is empty", errors.WithoutEvent())
@@ -282,9 +294,16 @@ func (r *Repository) LookupToken(ctx context.Context, authTokenId string, opt ..
 	return at, nil
 }
 
-// deleteKeyringToken deletes a keyring token
-func (r *Repository) deleteKeyringToken(ctx context.Context, kt KeyringToken) error {
-	const op = "cache.(Repository).deleteKeyringToken"
+// DeleteKeyringToken deletes a keyring token. If the auth token the keyring
+// token referenced is no longer referenced by any other token it is removed,
+// and if the token's user no longer has any auth tokens the user and all of
+// the user's cached resources are removed as well. Deleting a keyring token
+// that does not exist is a no-op.
+func (r *Repository) DeleteKeyringToken(ctx context.Context, kt KeyringToken) error {
+	const op = "cache.(Repository).DeleteKeyringToken"
+	if err := r.checkOpen(ctx, op); err != nil {
+		return err
+	}
 	switch {
 	case kt.KeyringType == "":
 		return errors.New(ctx, errors.InvalidParameter, op, "missing keyring type")
@@ -310,7 +329,8 @@ func (r *Repository) deleteKeyringToken(ctx context.Context, kt KeyringToken) er
 			}
 			return nil
 		case 0:
-			return errors.New(ctx, errors.RecordNotFound, op, "token not found when attempting deletion", errors.WithoutEvent())
+			// the token is already gone
+			return nil
 		default:
 			return errors.New(ctx, errors.MultipleRecords, op, "multiple tokens deleted when one was requested", errors.WithoutEvent())
 		}
@@ -321,10 +341,79 @@ func (r *Repository) deleteKeyringToken(ctx context.Context, kt KeyringToken) er
 	return nil
 }
 
+// CleanExpired reads every auth token stored in the keyring or in memory from
+// boundary and removes the ones which boundary reports as no longer valid.
+// Users left without an auth token are removed along with their cached
+// resources. Tokens which can't be read from boundary for any other reason,
+// such as a network failure, are kept and the errors are joined and returned
+// once all tokens have been checked.
+func (r *Repository) CleanExpired(ctx context.Context) error {
+	const op = "cache.(Repository).CleanExpired"
+	if err := r.checkOpen(ctx, op); err != nil {
+		return err
+	}
+	us, err := r.listUsers(ctx)
+	if err != nil {
+		return errors.Wrap(ctx, err, op)
+	}
+	var retErr error
+	for _, u := range us {
+		ats, err := r.listTokens(ctx, u)
+		if err != nil {
+			return errors.Wrap(ctx, err, op)
+		}
+		for _, at := range ats {
+			kts, err := r.listKeyringTokens(ctx, at)
+			if err != nil {
+				return errors.Wrap(ctx, err, op)
+			}
+			for _, kt := range kts {
+				kat := r.tokenKeyringFn(kt.KeyringType, kt.TokenName)
+				if kat == nil || kat.Id != kt.AuthTokenId {
+					continue
+				}
+				_, err := r.tokenReadFromBoundaryFn(ctx, u.Address, kat.Token)
+				switch {
+				case err != nil && (api.ErrUnauthorized.Is(err) || api.ErrNotFound.Is(err)):
+					if err := r.DeleteKeyringToken(ctx, *kt); err != nil {
+						return errors.Wrap(ctx, err, op)
+					}
+					event.WriteSysEvent(ctx, op, "Removed auth token from cache because it was not found to be valid in boundary", "auth token id", at.Id)
+				case err != nil:
+					retErr = stderrors.Join(retErr, errors.Wrap(ctx, err, op, errors.WithMsg("for auth token %q", at.Id)))
+				}
+			}
+			if v, ok := r.idToKeyringlessAuthToken.Load(at.Id); ok {
+				kat, ok := v.(*authtokens.AuthToken)
+				if !ok {
+					continue
+				}
+				_, err := r.tokenReadFromBoundaryFn(ctx, u.Address, kat.Token)
+				switch {
+				case err != nil && (api.ErrUnauthorized.Is(err) || api.ErrNotFound.Is(err)):
+					r.idToKeyringlessAuthToken.Delete(at.Id)
+					event.WriteSysEvent(ctx, op, "Removed auth token from cache because it was not found to be valid in boundary", "auth token id", at.Id)
+				case err != nil:
+					retErr = stderrors.Join(retErr, errors.Wrap(ctx, err, op, errors.WithMsg("for auth token %q", at.Id)))
+				}
+			}
+		}
+	}
+	// Remove the auth tokens no longer referenced by a keyring token or held
+	// in memory, which in turn removes users left without an auth token.
+	if err := r.cleanExpiredOrOrphanedAuthTokens(ctx); err != nil {
+		return stderrors.Join(retErr, errors.Wrap(ctx, err, op))
+	}
+	return retErr
+}
+
 // cleanExpiredOrOrphanedAuthTokens removes all tokens which are older than the staleness limit
 // or does not have either a keyring or keyringless reference to it.
 func (r *Repository) cleanExpiredOrOrphanedAuthTokens(ctx context.Context) error {
 	const op = "cache.Repository.cleanExpiredOrOrphanedAuthTokens"
+	if err := r.checkOpen(ctx, op); err != nil {
+		return err
+	}
 	_, err := r.rw.DoTx(ctx, db.StdRetryCnt, db.ExpBackoff{}, func(reader db.Reader, writer db.Writer) error {
 		if err := cleanExpiredOrOrphanedAuthTokens(ctx, writer, r.idToKeyringlessAuthToken); err != nil {
 			return errors.Wrap(ctx, err, op, errors.WithoutEvent())
@@ -455,6 +544,9 @@ func (r *Repository) listKeyringTokens(ctx context.Context, at *AuthToken) ([]*K
 // they are no longer represented in the db.
 func (r *Repository) syncKeyringlessTokensWithDb(ctx context.Context) error {
 	const op = "cache.(Repository).syncKeyringlessTokensWithDb"
+	if err := r.checkOpen(ctx, op); err != nil {
+		return err
+	}
 	var ret []*AuthToken
 	if err := r.rw.SearchWhere(ctx, &ret, "true", nil); err != nil {
 		return errors.Wrap(ctx, err, op)
@@ -5,12 +5,16 @@ package cache
 
 import (
 	"context"
+	stdErrors "errors"
 	"fmt"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/hashicorp/boundary/api"
 	"github.com/hashicorp/boundary/api/authtokens"
+	"github.com/hashicorp/boundary/api/sessions"
+	"github.com/hashicorp/boundary/api/targets"
 	cachedb "github.com/hashicorp/boundary/internal/clientcache/internal/db"
 	"github.com/hashicorp/boundary/internal/db"
 	"github.com/stretchr/testify/assert"
@@ -537,7 +541,7 @@ func TestRepository_DeleteKeyringToken(t *testing.T) {
 	require.NoError(t, err)
 
 	t.Run("delete non existing", func(t *testing.T) {
-		assert.ErrorContains(t, r.deleteKeyringToken(ctx, KeyringToken{KeyringType: "Unknown", TokenName: "Unknown"}), "not found")
+		assert.NoError(t, r.DeleteKeyringToken(ctx, KeyringToken{KeyringType: "Unknown", TokenName: "Unknown"}))
 	})
 
 	t.Run("delete existing", func(t *testing.T) {
@@ -546,7 +550,7 @@ func TestRepository_DeleteKeyringToken(t *testing.T) {
 		require.NoError(t, err)
 		require.NotNil(t, p)
 
-		assert.NoError(t, r.deleteKeyringToken(ctx, kt1))
+		assert.NoError(t, r.DeleteKeyringToken(ctx, kt1))
 
 		got, err := r.LookupToken(ctx, kt1.AuthTokenId)
 		require.NoError(t, err)
@@ -554,6 +558,82 @@ func TestRepository_DeleteKeyringToken(t *testing.T) {
 	})
 }
 
+func TestRepository_DeleteKeyringToken_CachedResources(t *testing.T) {
+	ctx := context.Background()
+	s, err := cachedb.Open(ctx)
+	require.NoError(t, err)
+
+	addr := "address"
+	u := &user{
+		Id:      "u_1",
+		Address: addr,
+	}
+	at1 := &authtokens.AuthToken{
+		Id:             "at_1",
+		Token:          "at_1_token",
+		UserId:         u.Id,
+		ExpirationTime: time.Now().Add(time.Minute),
+	}
+	kt1 := KeyringToken{
+		TokenName:   "t1",
+		KeyringType: "k1",
+		AuthTokenId: at1.Id,
+	}
+	at2 := &authtokens.AuthToken{
+		Id:             "at_2",
+		Token:          "at_2_token",
+		UserId:         u.Id,
+		ExpirationTime: time.Now().Add(time.Minute),
+	}
+	kt2 := KeyringToken{
+		TokenName:   "t2",
+		KeyringType: "k2",
+		AuthTokenId: at2.Id,
+	}
+
+	boundaryAuthTokens := []*authtokens.AuthToken{at1, at2}
+	atMap := map[ringToken]*authtokens.AuthToken{
+		{kt1.KeyringType, kt1.TokenName}: at1,
+		{kt2.KeyringType, kt2.TokenName}: at2,
+	}
+	r, err := NewRepository(ctx, s, &sync.Map{}, mapBasedAuthTokenKeyringLookup(atMap), sliceBasedAuthTokenBoundaryReader(boundaryAuthTokens))
+	require.NoError(t, err)
+	require.NoError(t, r.AddKeyringToken(ctx, addr, kt1))
+	require.NoError(t, r.AddKeyringToken(ctx, addr, kt2))
+
+	ts := []*targets.Target{target("1"), target("2")}
+	_, err = r.refreshTargets(ctx, u, map[AuthToken]string{{Id: "id"}: "something"},
+		WithTargetRetrievalFunc(testStaticResourceRetrievalFunc(t, [][]*targets.Target{ts}, [][]string{nil})))
+	require.NoError(t, err)
+	ss := []*sessions.Session{session("1"), session("2")}
+	require.NoError(t, r.refreshSessions(ctx, u, map[AuthToken]string{{Id: "id"}: "something"},
+		WithSessionRetrievalFunc(testStaticResourceRetrievalFunc(t, [][]*sessions.Session{ss}, [][]string{nil}))))
+
+	require.NoError(t, r.DeleteKeyringToken(ctx, kt1))
+
+	// The user still has a token so its cached resources are kept
+	gotTargets, err := r.ListTargets(ctx, at2.Id)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, ts, gotTargets)
+	gotSessions, err := r.ListSessions(ctx, at2.Id)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, ss, gotSessions)
+
+	require.NoError(t, r.DeleteKeyringToken(ctx, kt2))
+
+	// The last token for the user has been removed so the user and the
+	// cached resources are removed as well
+	gotUser, err := r.lookupUser(ctx, u.Id)
+	require.NoError(t, err)
+	assert.Nil(t, gotUser)
+	var cachedTargets []*Target
+	require.NoError(t, r.rw.SearchWhere(ctx, &cachedTargets, "fk_user_id = ?", []any{u.Id}, db.WithLimit(-1)))
+	assert.Empty(t, cachedTargets)
+	var cachedSessions []*Session
+	require.NoError(t, r.rw.SearchWhere(ctx, &cachedSessions, "fk_user_id = ?", []any{u.Id}, db.WithLimit(-1)))
+	assert.Empty(t, cachedSessions)
+}
+
 func TestRepository_LookupToken(t *testing.T) {
 	ctx := context.Background()
 	s, err := cachedb.Open(ctx)
@@ -864,3 +944,78 @@ func TestUpsertUserAndAuthToken(t *testing.T) {
 	})
 	require.NoError(t, err)
 }
+
+func TestRepository_CleanExpired(t *testing.T) {
+	ctx := context.Background()
+	s, err := cachedb.Open(ctx)
+	require.NoError(t, err)
+
+	addr := "address"
+	var us []*user
+	var ats []*authtokens.AuthToken
+	atMap := make(map[ringToken]*authtokens.AuthToken)
+	for i := 1; i <= 3; i++ {
+		u := &user{
+			Id:      fmt.Sprintf("u%d", i),
+			Address: addr,
+		}
+		at := &authtokens.AuthToken{
+			Id:             fmt.Sprintf("at_%d", i),
+			Token:          fmt.Sprintf("at_%d_token", i),
+			UserId:         u.Id,
+			ExpirationTime: time.Now().Add(time.Hour),
+		}
+		us = append(us, u)
+		ats = append(ats, at)
+		atMap[ringToken{"k", at.Id}] = at
+	}
+	valid, revoked, unreachable := ats[0], ats[1], ats[2]
+
+	var probing bool
+	boundaryReader := func(ctx context.Context, addr, tok string) (*authtokens.AuthToken, error) {
+		if probing {
+			switch tok {
+			case revoked.Token:
+				return nil, api.ErrUnauthorized
+			case unreachable.Token:
+				return nil, stdErrors.New("connection refused")
+			}
+		}
+		for _, at := range ats {
+			if at.Token == tok {
+				return at, nil
+			}
+		}
+		return nil, stdErrors.New("not found")
+	}
+	r, err := NewRepository(ctx, s, &sync.Map{}, mapBasedAuthTokenKeyringLookup(atMap), boundaryReader)
+	require.NoError(t, err)
+	for i, at := range ats {
+		require.NoError(t, r.AddKeyringToken(ctx, addr, KeyringToken{KeyringType: "k", TokenName: at.Id, AuthTokenId: at.Id}))
+		_, err = r.refreshTargets(ctx, us[i], map[AuthToken]string{{Id: "id"}: "something"},
+			WithTargetRetrievalFunc(testStaticResourceRetrievalFunc(t, [][]*targets.Target{{target(fmt.Sprint(i))}}, [][]string{nil})))
+		require.NoError(t, err)
+	}
+
+	probing = true
+	err = r.CleanExpired(ctx)
+	assert.ErrorContains(t, err, "connection refused")
+	assert.ErrorContains(t, err, unreachable.Id)
+
+	got, err := r.listUsers(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []*user{us[0], us[2]}, got)
+
+	for _, at := range []*authtokens.AuthToken{valid, unreachable} {
+		l, err := r.ListTargets(ctx, at.Id)
+		require.NoError(t, err)
+		assert.Len(t, l, 1)
+	}
+	l, err := r.ListTargets(ctx, revoked.Id)
+	require.NoError(t, err)
+	assert.Empty(t, l)
+
+	var cached []*Target
+	require.NoError(t, r.rw.SearchWhere(ctx, &cached, "fk_user_id = ?", []any{us[1].Id}))
+	assert.Empty(t, cached)
+}
@@ -88,14 +88,20 @@ func NewSearchService(ctx context.Context, repo *Repository) (*SearchService, er
 				},
 			},
 			Targets: &resourceSearchFns[*targets.Target]{
-				list:  repo.ListTargets,
-				query: repo.QueryTargets,
+				list: func(ctx context.Context, authTokenId string) ([]*targets.Target, error) {
+					return repo.ListTargets(ctx, authTokenId)
+				},
+				query: func(ctx context.Context, authTokenId, query string) ([]*targets.Target, error) {
+					return repo.QueryTargets(ctx, authTokenId, query)
+				},
 				searchResult: func(t []*targets.Target) *SearchResult {
 					return &SearchResult{Targets: t}
 				},
 			},
 			Sessions: &resourceSearchFns[*sessions.Session]{
-				list:  repo.ListSessions,
+				list: func(ctx context.Context, authTokenId string) ([]*sessions.Session, error) {
+					return repo.ListSessions(ctx, authTokenId)
+				},
 				query: repo.QuerySessions,
 				searchResult: func(s []*sessions.Session) *SearchResult {
 					return &SearchResult{Sessions: s}
@@ -189,11 +189,11 @@ func TestStatus(t *testing.T) {
 			target("3"),
 			target("4"),
 		}
-		err = r.refreshTargets(ctx, u1, map[AuthToken]string{{Id: "id"}: "something"},
+		_, err = r.refreshTargets(ctx, u1, map[AuthToken]string{{Id: "id"}: "something"},
 			WithTargetRetrievalFunc(testStaticResourceRetrievalFunc(t, [][]*targets.Target{ts}, [][]string{nil})))
 		require.NoError(t, err)
 
-		err = r.refreshTargets(ctx, u2, map[AuthToken]string{{Id: "id"}: "something"},
+		_, err = r.refreshTargets(ctx, u2, map[AuthToken]string{{Id: "id"}: "something"},
 			WithTargetRetrievalFunc(testStaticResourceRetrievalFunc(t, [][]*targets.Target{ts[:2]}, [][]string{nil})))
 		require.NoError(t, err)
 
@@ -311,7 +311,7 @@ func TestStatus_unsupported(t *testing.T) {
 		WithAliasRetrievalFunc(testNoRefreshRetrievalFunc[*aliases.Alias](t)))
 	require.ErrorIs(t, err, ErrRefreshNotSupported)
 
-	err = r.refreshTargets(ctx, u1, map[AuthToken]string{{Id: "id"}: "something"},
+	_, err = r.refreshTargets(ctx, u1, map[AuthToken]string{{Id: "id"}: "something"},
 		WithTargetRetrievalFunc(testNoRefreshRetrievalFunc[*targets.Target](t)))
 	require.ErrorIs(t, err, ErrRefreshNotSupported)
 
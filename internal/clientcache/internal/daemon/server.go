@@ -233,6 +233,13 @@ func (s *CacheServer) Serve(ctx context.Context, cmd Commander, opt ...Option) e
 		return errors.Wrap(ctx, err, op)
 	}
 	s.store.Store(store)
+	// The store is released once Serve returns, after the refresh tickers
+	// using it have stopped.
+	defer func() {
+		if err := cachedb.Close(ctx, store); err != nil {
+			event.WriteSysEvent(ctx, op, "error closing cache store", "err", err.Error())
+		}
+	}()
 	if s.conf.DatabaseUrl != "" {
 		s.info["Database URL"] = s.conf.DatabaseUrl
 		s.infoKeys = append(s.infoKeys, "Database URL")
@@ -266,10 +273,12 @@ func (s *CacheServer) Serve(ctx context.Context, cmd Commander, opt ...Option) e
 
 	s.printInfo(ctx)
 
-	repo, err := cache.NewRepository(ctx, s.store.Load(), &sync.Map{}, cmd.ReadTokenFromKeyring, opts.withBoundaryTokenReaderFunc)
+	repo, err := cache.NewRepository(ctx, s.store.Load(), &sync.Map{}, cmd.ReadTokenFromKeyring, opts.withBoundaryTokenReaderFunc,
+		cache.WithEventEmitter(cache.ObservationEventEmitter{}), cache.WithStaleThreshold(maxSearchStaleness))
 	if err != nil {
 		return errors.Wrap(ctx, err, op)
 	}
+	defer repo.MarkClosed()
 
 	refreshService, err := cache.NewRefreshService(ctx, repo, s.logger, maxSearchStaleness, maxSearchRefreshTimeout)
 	if err != nil {
@@ -281,8 +290,11 @@ func (s *CacheServer) Serve(ctx context.Context, cmd Commander, opt ...Option) e
 	}
 
 	tickingCtx, cancel := context.WithCancel(ctx)
-	defer cancel()
 	var tickerWg sync.WaitGroup
+	defer func() {
+		cancel()
+		tickerWg.Wait()
+	}()
 	tickerWg.Add(2)
 	go func() {
 		defer tickerWg.Done()
@@ -341,8 +353,6 @@ func (s *CacheServer) Serve(ctx context.Context, cmd Commander, opt ...Option) e
 	if err = s.httpSrv.Serve(l); err != nil && err != http.ErrServerClosed && !errors.Is(err, net.ErrClosed) {
 		event.WriteSysEvent(ctx, op, "error closing server", "err", err.Error())
 	}
-	cancel()
-	tickerWg.Wait()
 
 	return nil
 }
@@ -11,7 +11,9 @@ import (
 
 	"github.com/hashicorp/boundary/api/aliases"
 	"github.com/hashicorp/boundary/api/authtokens"
+	"github.com/hashicorp/boundary/api/hosts"
 	"github.com/hashicorp/boundary/api/sessions"
+	"github.com/hashicorp/boundary/api/storagebuckets"
 	"github.com/hashicorp/boundary/api/targets"
 	"github.com/hashicorp/boundary/internal/clientcache/internal/cache"
 	"github.com/hashicorp/go-hclog"
@@ -106,9 +108,15 @@ func (s *TestServer) AddResources(t *testing.T, p *authtokens.AuthToken, alts []
 		}
 		return sess, nil, "addedsessions", nil
 	}
+	hostFn := func(_ context.Context, _, _ string, _ cache.RefreshTokenValue) ([]*hosts.Host, []string, cache.RefreshTokenValue, error) {
+		return nil, nil, "", nil
+	}
+	sbFn := func(_ context.Context, _, _ string, _ cache.RefreshTokenValue) ([]*storagebuckets.StorageBucket, []string, cache.RefreshTokenValue, error) {
+		return nil, nil, "", nil
+	}
 	rs, err := cache.NewRefreshService(ctx, r, hclog.NewNullLogger(), 0, 0)
 	require.NoError(t, err)
-	require.NoError(t, rs.Refresh(ctx, cache.WithAliasRetrievalFunc(altFn), cache.WithTargetRetrievalFunc(tarFn), cache.WithSessionRetrievalFunc(sessFn)))
+	require.NoError(t, rs.Refresh(ctx, cache.WithAliasRetrievalFunc(altFn), cache.WithTargetRetrievalFunc(tarFn), cache.WithSessionRetrievalFunc(sessFn), cache.WithHostRetrievalFunc(hostFn), cache.WithStorageBucketRetrievalFunc(sbFn)))
 }
 
 // AddUnsupportedCachingData provides data in a way that simulates it coming from
@@ -135,8 +143,20 @@ func (s *TestServer) AddUnsupportedCachingData(t *testing.T, p *authtokens.AuthT
 		}
 		return []*sessions.Session{}, nil, "", cache.ErrRefreshNotSupported
 	}
+	hostFn := func(_ context.Context, _, tok string, _ cache.RefreshTokenValue) ([]*hosts.Host, []string, cache.RefreshTokenValue, error) {
+		if tok != p.Token {
+			return nil, nil, "", nil
+		}
+		return nil, nil, "", cache.ErrRefreshNotSupported
+	}
+	sbFn := func(_ context.Context, _, tok string, _ cache.RefreshTokenValue) ([]*storagebuckets.StorageBucket, []string, cache.RefreshTokenValue, error) {
+		if tok != p.Token {
+			return nil, nil, "", nil
+		}
+		return nil, nil, "", cache.ErrRefreshNotSupported
+	}
 	rs, err := cache.NewRefreshService(ctx, r, hclog.NewNullLogger(), 0, 0)
 	require.NoError(t, err)
-	err = rs.Refresh(ctx, cache.WithTargetRetrievalFunc(tarFn), cache.WithSessionRetrievalFunc(sessFn))
+	err = rs.Refresh(ctx, cache.WithTargetRetrievalFunc(tarFn), cache.WithSessionRetrievalFunc(sessFn), cache.WithHostRetrievalFunc(hostFn), cache.WithStorageBucketRetrievalFunc(sbFn))
 	require.ErrorContains(t, err, "not supported for this controller")
 }
@@ -5,8 +5,9 @@ package db
 
 import (
 	"context"
-	_ "embed"
 	"fmt"
+	"strings"
+	"sync"
 
 	"github.com/hashicorp/boundary/internal/db"
 	"github.com/hashicorp/boundary/internal/errors"
@@ -14,14 +15,33 @@ import (
 	"github.com/hashicorp/go-dbw"
 )
 
-//go:embed schema.sql
-var cacheSchema string
-
 // DefaultStoreUrl uses a temp in-memory sqlite database see: https://www.sqlite.org/inmemorydb.html
 const DefaultStoreUrl = "file::memory:?_pragma=foreign_keys(1)"
 
+// sharedStores holds the stores opened for file backed urls, keyed by url, so
+// that every Open of the same file shares a single connection. Writes from
+// separate connections to the same sqlite file aren't serialized and can
+// corrupt it.
+var (
+	sharedStoresMu sync.Mutex
+	sharedStores   = map[string]*sharedStore{}
+)
+
+type sharedStore struct {
+	conn *db.DB
+	refs int
+}
+
 // Open creates a database connection. WithUrl is supported, but by default it
-// uses an in memory sqlite table. Sqlite is the only supported dbtype.
+// uses an in memory sqlite table. Sqlite is the only supported dbtype. Any
+// schema migrations not yet applied to the store are applied before returning
+// and an error is returned if the store has a newer schema than is supported.
+//
+// Opening a file backed url which is already open returns the same connection
+// and the options of the first Open are kept. Each store returned must be
+// released with Close instead of closing the connection directly, so that the
+// connection is only closed once nothing else is using it. In memory stores
+// are never shared.
 func Open(ctx context.Context, opt ...Option) (*db.DB, error) {
 	const op = "db.Open"
 	opts, err := getOpts(opt...)
@@ -36,6 +56,16 @@ func Open(ctx context.Context, opt ...Option) (*db.DB, error) {
 		url = DefaultStoreUrl
 	}
 
+	shared := !isInMemory(url)
+	if shared {
+		sharedStoresMu.Lock()
+		defer sharedStoresMu.Unlock()
+		if s, ok := sharedStores[url]; ok {
+			s.refs++
+			return s.conn, nil
+		}
+	}
+
 	dbOpts := []db.Option{db.WithMaxOpenConnections(1)}
 	if !util.IsNil(opts.withGormFormatter) {
 		dbOpts = append(dbOpts, db.WithGormFormatter(opts.withGormFormatter))
@@ -51,20 +81,46 @@ func Open(ctx context.Context, opt ...Option) (*db.DB, error) {
 
 	switch {
 	case opts.withDbType == dbw.Sqlite:
-		if err := createTables(ctx, conn); err != nil {
+		if err := migrate(ctx, conn); err != nil {
 			return nil, errors.Wrap(ctx, err, op)
 		}
 	default:
 		return nil, errors.New(ctx, errors.InvalidParameter, op, fmt.Sprintf("%q is not a supported cache store type", opts.withDbType))
 	}
+	if shared {
+		sharedStores[url] = &sharedStore{conn: conn, refs: 1}
+	}
 	return conn, nil
 }
 
-func createTables(ctx context.Context, conn *db.DB) error {
-	const op = "db.createTables"
-	rw := db.New(conn)
-	if _, err := rw.Exec(ctx, cacheSchema, nil); err != nil {
+// Close releases a store returned by Open. The underlying connection is
+// closed once every Open which returned it has been released.
+func Close(ctx context.Context, conn *db.DB) error {
+	const op = "db.Close"
+	if conn == nil {
+		return errors.New(ctx, errors.InvalidParameter, op, "missing store")
+	}
+	sharedStoresMu.Lock()
+	defer sharedStoresMu.Unlock()
+	for url, s := range sharedStores {
+		if s.conn != conn {
+			continue
+		}
+		s.refs--
+		if s.refs > 0 {
+			return nil
+		}
+		delete(sharedStores, url)
+		break
+	}
+	if err := conn.Close(ctx); err != nil {
 		return errors.Wrap(ctx, err, op)
 	}
 	return nil
 }
+
+// isInMemory reports whether the sqlite url is for an in memory database, see:
+// https://www.sqlite.org/inmemorydb.html
+func isInMemory(url string) bool {
+	return strings.Contains(url, ":memory:") || strings.Contains(url, "mode=memory")
+}
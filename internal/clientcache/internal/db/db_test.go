@@ -0,0 +1,108 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package db
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/boundary/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpen_Shared(t *testing.T) {
+	ctx := context.Background()
+	testUrl := func(t *testing.T) string {
+		return fmt.Sprintf("file:%s?_pragma=foreign_keys(1)", filepath.Join(t.TempDir(), "cache.db"))
+	}
+	queryString := func(t *testing.T, conn *db.DB, query string) string {
+		rows, err := db.New(conn).Query(ctx, query, nil)
+		require.NoError(t, err)
+		defer rows.Close()
+		require.True(t, rows.Next())
+		var got string
+		require.NoError(t, rows.Scan(&got))
+		require.NoError(t, rows.Err())
+		return got
+	}
+
+	t.Run("same url", func(t *testing.T) {
+		url := testUrl(t)
+		conn, err := Open(ctx, WithUrl(url))
+		require.NoError(t, err)
+
+		const routines = 20
+		var wg sync.WaitGroup
+		conns := make([]*db.DB, routines)
+		errs := make([]error, routines)
+		for i := 0; i < routines; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				c, err := Open(ctx, WithUrl(url))
+				if err != nil {
+					errs[i] = err
+					return
+				}
+				conns[i] = c
+				if _, err := db.New(c).Exec(ctx, "insert into user (id, address) values (?, 'address')", []any{fmt.Sprintf("u_%d", i)}); err != nil {
+					errs[i] = err
+				}
+				if err := Close(ctx, c); err != nil && errs[i] == nil {
+					errs[i] = err
+				}
+			}(i)
+		}
+		wg.Wait()
+		for i := 0; i < routines; i++ {
+			require.NoError(t, errs[i])
+			assert.Same(t, conn, conns[i])
+		}
+
+		// The connection is still usable since the first Open wasn't released.
+		assert.Equal(t, fmt.Sprint(routines), queryString(t, conn, "select count(*) from user"))
+		require.NoError(t, Close(ctx, conn))
+
+		// Once every Open is released the connection is closed and the next
+		// Open creates a new one to the intact store.
+		_, err = db.New(conn).Exec(ctx, "select 1", nil)
+		assert.Error(t, err)
+		conn, err = Open(ctx, WithUrl(url))
+		require.NoError(t, err)
+		t.Cleanup(func() { Close(ctx, conn) })
+		assert.Equal(t, fmt.Sprint(routines), queryString(t, conn, "select count(*) from user"))
+		assert.Equal(t, "ok", queryString(t, conn, "pragma integrity_check"))
+	})
+
+	t.Run("different urls", func(t *testing.T) {
+		conn1, err := Open(ctx, WithUrl(testUrl(t)))
+		require.NoError(t, err)
+		conn2, err := Open(ctx, WithUrl(testUrl(t)))
+		require.NoError(t, err)
+		t.Cleanup(func() { Close(ctx, conn2) })
+		assert.NotSame(t, conn1, conn2)
+
+		require.NoError(t, Close(ctx, conn1))
+		_, err = db.New(conn2).Exec(ctx, "insert into user (id, address) values ('u_1', 'address')", nil)
+		assert.NoError(t, err)
+	})
+
+	t.Run("in memory", func(t *testing.T) {
+		conn1, err := Open(ctx)
+		require.NoError(t, err)
+		conn2, err := Open(ctx)
+		require.NoError(t, err)
+		assert.NotSame(t, conn1, conn2)
+		require.NoError(t, Close(ctx, conn1))
+		require.NoError(t, Close(ctx, conn2))
+	})
+
+	t.Run("missing store", func(t *testing.T) {
+		assert.ErrorContains(t, Close(ctx, nil), "missing store")
+	})
+}
@@ -0,0 +1,161 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package db
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+
+	"github.com/hashicorp/boundary/internal/db"
+	"github.com/hashicorp/boundary/internal/errors"
+)
+
+//go:embed schema.sql
+var cacheSchema string
+
+//go:embed migrations/02_refresh_time.sql
+var refreshTimeSchema string
+
+//go:embed migrations/03_target_columns.sql
+var targetColumnsSchema string
+
+//go:embed migrations/04_host_storage_bucket.sql
+var hostStorageBucketSchema string
+
+// schemaVersionTable records every migration that has been applied to the
+// cache store.
+const schemaVersionTable = `
+create table if not exists schema_version (
+  version integer not null primary key
+    check (version > 0),
+  applied_time timestamp not null default (strftime('%Y-%m-%d %H:%M:%f','now'))
+);
+`
+
+// migration is a change to the cache schema identified by its version.
+type migration struct {
+	version int
+	sql     string
+}
+
+// migrations are the changes to the cache schema in the order they are
+// applied. A change to the schema must be added as a new migration with the
+// next version instead of editing an existing one, since stores which have
+// already applied a version never apply it again.
+var migrations = []migration{
+	// Version 1 is the schema used before it was versioned. See
+	// applyBaselineSchema for how it is applied.
+	{version: 1, sql: cacheSchema},
+	{version: 2, sql: refreshTimeSchema},
+	{version: 3, sql: targetColumnsSchema},
+	{version: 4, sql: hostStorageBucketSchema},
+}
+
+// migrate applies, in order, each migration that has not yet been applied to
+// the store and records it in the schema_version table. An error is returned
+// if the store has already been migrated past the latest known version, since
+// downgrading the schema is not supported.
+func migrate(ctx context.Context, conn *db.DB) error {
+	const op = "db.migrate"
+	rw := db.New(conn)
+	if _, err := rw.Exec(ctx, schemaVersionTable, nil); err != nil {
+		return errors.Wrap(ctx, err, op)
+	}
+	current, err := schemaVersion(ctx, rw)
+	if err != nil {
+		return errors.Wrap(ctx, err, op)
+	}
+	latest := migrations[len(migrations)-1].version
+	if current > latest {
+		return errors.New(ctx, errors.MigrationIntegrity, op, fmt.Sprintf("cache schema version %d is newer than the latest supported version %d", current, latest))
+	}
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		if m.version == 1 {
+			if err := applyBaselineSchema(ctx, rw, m); err != nil {
+				return errors.Wrap(ctx, err, op)
+			}
+			continue
+		}
+		_, err := rw.DoTx(ctx, db.StdRetryCnt, db.ExpBackoff{}, func(_ db.Reader, w db.Writer) error {
+			if _, err := w.Exec(ctx, m.sql, nil); err != nil {
+				return err
+			}
+			if _, err := w.Exec(ctx, "insert into schema_version (version) values (?)", []any{m.version}); err != nil {
+				return err
+			}
+			return nil
+		})
+		if err != nil {
+			return errors.Wrap(ctx, err, op, errors.WithMsg(fmt.Sprintf("applying migration %d", m.version)))
+		}
+	}
+	return nil
+}
+
+// applyBaselineSchema applies the first migration, which is the schema used
+// before the schema was versioned. Its sql starts and commits its own
+// transaction so it isn't run inside of one. Stores created before the schema
+// was versioned already have it applied, so for those it is only recorded.
+func applyBaselineSchema(ctx context.Context, rw *db.Db, m migration) error {
+	const op = "db.applyBaselineSchema"
+	applied, err := hasBaselineSchema(ctx, rw)
+	if err != nil {
+		return errors.Wrap(ctx, err, op)
+	}
+	if !applied {
+		if _, err := rw.Exec(ctx, m.sql, nil); err != nil {
+			return errors.Wrap(ctx, err, op, errors.WithMsg(fmt.Sprintf("applying migration %d", m.version)))
+		}
+	}
+	if _, err := rw.Exec(ctx, "insert into schema_version (version) values (?)", []any{m.version}); err != nil {
+		return errors.Wrap(ctx, err, op)
+	}
+	return nil
+}
+
+// hasBaselineSchema reports whether the tables of the first migration already
+// exist in the store.
+func hasBaselineSchema(ctx context.Context, r db.Reader) (bool, error) {
+	const op = "db.hasBaselineSchema"
+	rows, err := r.Query(ctx, "select count(*) from sqlite_master where type = 'table' and name = 'user'", nil)
+	if err != nil {
+		return false, errors.Wrap(ctx, err, op)
+	}
+	defer rows.Close()
+	var count int
+	for rows.Next() {
+		if err := rows.Scan(&count); err != nil {
+			return false, errors.Wrap(ctx, err, op)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return false, errors.Wrap(ctx, err, op)
+	}
+	return count > 0, nil
+}
+
+// schemaVersion returns the latest migration version applied to the store or
+// 0 if none have been applied.
+func schemaVersion(ctx context.Context, r db.Reader) (int, error) {
+	const op = "db.schemaVersion"
+	rows, err := r.Query(ctx, "select coalesce(max(version), 0) from schema_version", nil)
+	if err != nil {
+		return 0, errors.Wrap(ctx, err, op)
+	}
+	defer rows.Close()
+	var version int
+	for rows.Next() {
+		if err := rows.Scan(&version); err != nil {
+			return 0, errors.Wrap(ctx, err, op)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, errors.Wrap(ctx, err, op)
+	}
+	return version, nil
+}
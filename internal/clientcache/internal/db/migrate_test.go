@@ -0,0 +1,187 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package db
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/boundary/internal/db"
+	"github.com/hashicorp/boundary/internal/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unversionedSchema is the cache schema used before the schema was versioned.
+// It must not be changed since it is the schema of existing stores.
+//
+//go:embed testdata/unversioned_schema.sql
+var unversionedSchema string
+
+func TestOpen_Migrations(t *testing.T) {
+	ctx := context.Background()
+	latest := migrations[len(migrations)-1].version
+	testUrl := func(t *testing.T) string {
+		return fmt.Sprintf("file:%s?_pragma=foreign_keys(1)", filepath.Join(t.TempDir(), "cache.db"))
+	}
+	appliedVersions := func(t *testing.T, conn *db.DB) []int {
+		rows, err := db.New(conn).Query(ctx, "select version from schema_version order by version", nil)
+		require.NoError(t, err)
+		defer rows.Close()
+		var got []int
+		for rows.Next() {
+			var v int
+			require.NoError(t, rows.Scan(&v))
+			got = append(got, v)
+		}
+		require.NoError(t, rows.Err())
+		return got
+	}
+	userIds := func(t *testing.T, conn *db.DB) []string {
+		rows, err := db.New(conn).Query(ctx, "select id from user order by id", nil)
+		require.NoError(t, err)
+		defer rows.Close()
+		var got []string
+		for rows.Next() {
+			var id string
+			require.NoError(t, rows.Scan(&id))
+			got = append(got, id)
+		}
+		require.NoError(t, rows.Err())
+		return got
+	}
+	addUser := func(t *testing.T, conn *db.DB, id string) {
+		_, err := db.New(conn).Exec(ctx, "insert into user (id, address) values (?, 'address')", []any{id})
+		require.NoError(t, err)
+	}
+
+	t.Run("new store", func(t *testing.T) {
+		conn, err := Open(ctx)
+		require.NoError(t, err)
+		got, err := schemaVersion(ctx, db.New(conn))
+		require.NoError(t, err)
+		assert.Equal(t, latest, got)
+	})
+
+	t.Run("reopen is a no-op", func(t *testing.T) {
+		url := testUrl(t)
+		conn, err := Open(ctx, WithUrl(url))
+		require.NoError(t, err)
+		addUser(t, conn, "u_1")
+		require.NoError(t, Close(ctx, conn))
+
+		conn, err = Open(ctx, WithUrl(url))
+		require.NoError(t, err)
+		t.Cleanup(func() { Close(ctx, conn) })
+		assert.Len(t, appliedVersions(t, conn), len(migrations))
+		assert.Equal(t, []string{"u_1"}, userIds(t, conn))
+	})
+
+	t.Run("unversioned store", func(t *testing.T) {
+		// Stores created before the schema was versioned have the tables from
+		// the first migration but no schema_version table.
+		url := testUrl(t)
+		conn, err := db.Open(ctx, db.Sqlite, url, db.WithMaxOpenConnections(1))
+		require.NoError(t, err)
+		rw := db.New(conn)
+		_, err = rw.Exec(ctx, unversionedSchema, nil)
+		require.NoError(t, err)
+		addUser(t, conn, "u_1")
+		_, err = rw.Exec(ctx, "insert into target (fk_user_id, id, name, item) values ('u_1', 'ttcp_1', 'name1', '{}')", nil)
+		require.NoError(t, err)
+		_, err = rw.Exec(ctx, "insert into refresh_token (user_id, resource_type, refresh_token) values ('u_1', 'target', 'token')", nil)
+		require.NoError(t, err)
+		require.NoError(t, Close(ctx, conn))
+
+		conn, err = Open(ctx, WithUrl(url))
+		require.NoError(t, err)
+		t.Cleanup(func() { Close(ctx, conn) })
+		rw = db.New(conn)
+		got, err := schemaVersion(ctx, rw)
+		require.NoError(t, err)
+		assert.Equal(t, latest, got)
+		assert.Len(t, appliedVersions(t, conn), len(migrations))
+		assert.Equal(t, []string{"u_1"}, userIds(t, conn))
+
+		// The existing target is kept and has the columns added since.
+		rows, err := rw.Query(ctx, "select name, session_max_seconds, first_seen_time from target where id = 'ttcp_1'", nil)
+		require.NoError(t, err)
+		require.True(t, rows.Next())
+		var name string
+		var maxSeconds *int
+		var firstSeen string
+		require.NoError(t, rows.Scan(&name, &maxSeconds, &firstSeen))
+		require.NoError(t, rows.Close())
+		assert.Equal(t, "name1", name)
+		assert.Nil(t, maxSeconds)
+		assert.NotEmpty(t, firstSeen)
+
+		// The existing refresh token is kept and the resource types added
+		// since can be used.
+		rows, err = rw.Query(ctx, "select count(*) from refresh_token where user_id = 'u_1' and resource_type = 'target'", nil)
+		require.NoError(t, err)
+		require.True(t, rows.Next())
+		var count int
+		require.NoError(t, rows.Scan(&count))
+		require.NoError(t, rows.Close())
+		assert.Equal(t, 1, count)
+		for _, rt := range []string{"host", "storage_bucket"} {
+			_, err = rw.Exec(ctx, "insert into refresh_token (user_id, resource_type, refresh_token) values ('u_1', ?, 'token')", []any{rt})
+			assert.NoError(t, err, rt)
+		}
+		_, err = rw.Exec(ctx, "insert into refresh_token (user_id, resource_type, refresh_token) values ('u_1', 'unknown_type', 'token')", nil)
+		assert.Error(t, err)
+		_, err = rw.Exec(ctx, "insert into host (fk_user_id, id) values ('u_1', 'hst_1')", nil)
+		assert.NoError(t, err)
+		_, err = rw.Exec(ctx, "insert into storage_bucket (fk_user_id, id) values ('u_1', 'sb_1')", nil)
+		assert.NoError(t, err)
+	})
+
+	t.Run("old schema", func(t *testing.T) {
+		url := testUrl(t)
+		conn, err := Open(ctx, WithUrl(url))
+		require.NoError(t, err)
+		addUser(t, conn, "u_1")
+		require.NoError(t, Close(ctx, conn))
+
+		orig := migrations
+		t.Cleanup(func() { migrations = orig })
+		migrations = append(migrations[:len(migrations):len(migrations)],
+			migration{version: latest + 1, sql: "alter table user add column note text;"},
+			migration{version: latest + 2, sql: "update user set note = 'migrated';"},
+		)
+
+		conn, err = Open(ctx, WithUrl(url))
+		require.NoError(t, err)
+		t.Cleanup(func() { Close(ctx, conn) })
+		versions := appliedVersions(t, conn)
+		require.Len(t, versions, len(migrations))
+		assert.Equal(t, []int{latest + 1, latest + 2}, versions[len(versions)-2:])
+		assert.Equal(t, []string{"u_1"}, userIds(t, conn))
+
+		rows, err := db.New(conn).Query(ctx, "select note from user where id = 'u_1'", nil)
+		require.NoError(t, err)
+		defer rows.Close()
+		require.True(t, rows.Next())
+		var note string
+		require.NoError(t, rows.Scan(&note))
+		assert.Equal(t, "migrated", note)
+	})
+
+	t.Run("refuses downgrade", func(t *testing.T) {
+		url := testUrl(t)
+		conn, err := Open(ctx, WithUrl(url))
+		require.NoError(t, err)
+		_, err = db.New(conn).Exec(ctx, "insert into schema_version (version) values (?)", []any{latest + 1})
+		require.NoError(t, err)
+		require.NoError(t, Close(ctx, conn))
+
+		_, err = Open(ctx, WithUrl(url))
+		assert.Truef(t, errors.Match(errors.T(errors.MigrationIntegrity), err), "unexpected error: %v", err)
+		assert.ErrorContains(t, err, "is newer than the latest supported version")
+	})
+}
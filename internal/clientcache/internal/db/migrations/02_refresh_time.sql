@@ -0,0 +1,15 @@
+-- Copyright (c) HashiCorp, Inc.
+-- SPDX-License-Identifier: BUSL-1.1
+
+-- Contains the last time the resources of a specific type were refreshed for a
+-- user, whether or not the refresh returned a refresh token.
+create table if not exists refresh_time(
+  user_id text not null
+    references user(id)
+    on delete cascade,
+  resource_type text not null
+    references resource_type_enm(string)
+    constraint only_known_resource_types_allowed,
+  refresh_time timestamp not null,
+  primary key (user_id, resource_type)
+);
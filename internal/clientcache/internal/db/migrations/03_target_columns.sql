@@ -0,0 +1,39 @@
+-- Copyright (c) HashiCorp, Inc.
+-- SPDX-License-Identifier: BUSL-1.1
+
+-- Adds the session max seconds of a target and the time it was first cached.
+-- Sqlite can't add a column with a non constant default to an existing table
+-- so the target table is rebuilt with the new columns.
+create table target_new (
+  -- the boundary user id of the user who has was able to read/list this target
+  fk_user_id text not null
+    references user(id)
+    on delete cascade,
+  -- the boundary id of the target
+  id text not null
+    check (length(id) > 0),
+  -- the following fields are used for searching and are set to the values
+  -- from the boundary resource
+  name text,
+  description text,
+  type text,
+  address text,
+  scope_id text,
+  session_max_seconds integer,
+  -- item is the json representation of this resource from the perspective of
+  -- the the requesting user.
+  item text,
+  -- the first time this target was cached for the user
+  first_seen_time timestamp not null default (strftime('%Y-%m-%d %H:%M:%f','now')),
+  primary key (fk_user_id, id)
+);
+
+insert into target_new
+  (fk_user_id, id, name, description, type, address, scope_id, item)
+select
+  fk_user_id, id, name, description, type, address, scope_id, item
+from target;
+
+drop table target;
+
+alter table target_new rename to target;
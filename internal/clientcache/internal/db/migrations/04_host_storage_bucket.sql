@@ -0,0 +1,70 @@
+-- Copyright (c) HashiCorp, Inc.
+-- SPDX-License-Identifier: BUSL-1.1
+
+-- Adds the host and storage bucket resource types. Sqlite can't alter a check
+-- constraint so resource_type_enm is recreated. Foreign keys referencing it are
+-- deferred so the rows referencing the dropped table are satisfied again by the
+-- time the transaction commits.
+pragma defer_foreign_keys = on;
+
+drop table resource_type_enm;
+
+create table resource_type_enm(
+  string text not null primary key
+    constraint only_predefined_resource_types_allowed
+    check(string in ('unknown', 'alias', 'target', 'session', 'host', 'storage_bucket'))
+);
+
+insert into resource_type_enm (string)
+values
+  ('unknown'),
+  ('alias'),
+  ('target'),
+  ('session'),
+  ('host'),
+  ('storage_bucket');
+
+-- host contains cached boundary host resource for a specific user and
+-- with specific fields extracted to facilitate searching over those fields
+create table host (
+  -- the boundary user id of the user who has was able to read/list this resource
+  fk_user_id text not null
+    references user(id)
+    on delete cascade,
+  -- the resource id from boundary of this host
+  id text not null
+    check (length(id) > 0),
+  -- the following fields are used for searching and are set to the values
+  -- from the boundary resource
+  host_catalog_id text,
+  name text,
+  description text,
+  type text,
+  external_id text,
+  external_name text,
+  -- item is the json representation of this resource from the perspective of
+  -- of the user whose id is set in fk_user_id
+  item text,
+  primary key (fk_user_id, id)
+);
+
+-- storage_bucket contains cached boundary storage bucket resource for a
+-- specific user and with specific fields extracted to facilitate searching
+-- over those fields
+create table storage_bucket (
+  -- the boundary user id of the user who has was able to read/list this resource
+  fk_user_id text not null
+    references user(id)
+    on delete cascade,
+  -- the resource id from boundary of this storage bucket
+  id text not null
+    check (length(id) > 0),
+  -- the following fields are used for searching and are set to the values
+  -- from the boundary resource
+  name text,
+  scope_id text,
+  -- item is the json representation of this resource from the perspective of
+  -- of the user whose id is set in fk_user_id
+  item text,
+  primary key (fk_user_id, id)
+);
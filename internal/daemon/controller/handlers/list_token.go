@@ -135,52 +135,13 @@ func MarshalListToken(ctx context.Context, token *listtoken.Token, resourceType
 }
 
 // ListTokenResourceToResource translates a protobuf list token resource type
-// into a useable domain layer boundary resource type.
+// into a useable domain layer boundary resource type. Unmapped values are
+// translated to resource.Unknown; use resource.FromProto to get an error
+// instead.
 func ListTokenResourceToResource(rt pbs.ResourceType) resource.Type {
-	switch rt {
-	case pbs.ResourceType_RESOURCE_TYPE_ACCOUNT:
-		return resource.Account
-	case pbs.ResourceType_RESOURCE_TYPE_ALIAS:
-		return resource.Alias
-	case pbs.ResourceType_RESOURCE_TYPE_AUTH_METHOD:
-		return resource.AuthMethod
-	case pbs.ResourceType_RESOURCE_TYPE_AUTH_TOKEN:
-		return resource.AuthToken
-	case pbs.ResourceType_RESOURCE_TYPE_CREDENTIAL_LIBRARY:
-		return resource.CredentialLibrary
-	case pbs.ResourceType_RESOURCE_TYPE_CREDENTIAL_STORE:
-		return resource.CredentialStore
-	case pbs.ResourceType_RESOURCE_TYPE_CREDENTIAL:
-		return resource.Credential
-	case pbs.ResourceType_RESOURCE_TYPE_GROUP:
-		return resource.Group
-	case pbs.ResourceType_RESOURCE_TYPE_HOST_CATALOG:
-		return resource.HostCatalog
-	case pbs.ResourceType_RESOURCE_TYPE_HOST_SET:
-		return resource.HostSet
-	case pbs.ResourceType_RESOURCE_TYPE_HOST:
-		return resource.Host
-	case pbs.ResourceType_RESOURCE_TYPE_MANAGED_GROUP:
-		return resource.ManagedGroup
-	case pbs.ResourceType_RESOURCE_TYPE_ROLE:
-		return resource.Role
-	case pbs.ResourceType_RESOURCE_TYPE_SCOPE:
-		return resource.Scope
-	case pbs.ResourceType_RESOURCE_TYPE_SESSION_RECORDING:
-		return resource.SessionRecording
-	case pbs.ResourceType_RESOURCE_TYPE_SESSION:
-		return resource.Session
-	case pbs.ResourceType_RESOURCE_TYPE_STORAGE_BUCKET:
-		return resource.StorageBucket
-	case pbs.ResourceType_RESOURCE_TYPE_TARGET:
-		return resource.Target
-	case pbs.ResourceType_RESOURCE_TYPE_USER:
-		return resource.User
-	case pbs.ResourceType_RESOURCE_TYPE_WORKER:
-		return resource.Worker
-	case pbs.ResourceType_RESOURCE_TYPE_POLICY:
-		return resource.Policy
-	default:
+	t, err := resource.FromProto(rt)
+	if err != nil {
 		return resource.Unknown
 	}
+	return t
 }
@@ -635,13 +635,13 @@ func Parse(ctx context.Context, scopeId, grantString string, opt ...Option) (Gra
 }
 
 // validateType ensures that we are not allowing access to disallowed resource
-// types. It does not explicitly check the resource string itself; that's the
-// job of the parsing functions to look up the string from the Map and ensure
-// it's not unknown.
+// types, as determined by resource.Type.IsGrantable. It does not explicitly
+// check the resource string itself; that's the job of the parsing functions to
+// look up the string from the Map and ensure it's not unknown. An unknown type
+// means no type was specified and is allowed.
 func (g Grant) validateType(ctx context.Context) error {
 	const op = "perms.(Grant).validateType"
-	switch g.typ {
-	case resource.Controller:
+	if g.typ != resource.Unknown && !g.typ.IsGrantable() {
 		return errors.New(ctx, errors.InvalidParameter, op, fmt.Sprintf("unknown type specifier %q", g.typ))
 	}
 	return nil
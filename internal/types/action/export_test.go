@@ -0,0 +1,18 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package action
+
+import "testing"
+
+// ResetRegistrarForTest is a test helper which replaces the registered
+// resources with an empty set for the duration of the test, so the test can
+// register resources without depending on or affecting other tests.
+func ResetRegistrarForTest(t *testing.T) {
+	t.Helper()
+	old := byResourceRegistrar
+	byResourceRegistrar = &byResource{}
+	t.Cleanup(func() {
+		byResourceRegistrar = old
+	})
+}
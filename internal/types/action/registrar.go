@@ -71,3 +71,13 @@ func ActionSetForResource(r resource.Type) (ActionSet, error) {
 	}
 	return a.valid, nil
 }
+
+// SupportsAction indicates whether a is one of the actions registered for r.
+// It returns false if r has not been registered.
+func SupportsAction(r resource.Type, a Type) bool {
+	sets, err := byResourceRegistrar.get(r)
+	if err != nil {
+		return false
+	}
+	return sets.valid.HasAction(a)
+}
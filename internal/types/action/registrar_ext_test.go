@@ -14,6 +14,8 @@ import (
 )
 
 func TestRegisterResource(t *testing.T) {
+	action.ResetRegistrarForTest(t)
+
 	t.Run("Pancis", func(t *testing.T) {
 		// Ensure the resource is not registered yet
 		_, err := action.ActionSetForResource(resource.Session)
@@ -69,3 +71,30 @@ func TestRegisterResource(t *testing.T) {
 		}
 	})
 }
+
+func TestSupportsAction(t *testing.T) {
+	action.ResetRegistrarForTest(t)
+	action.RegisterResource(resource.Target, action.NewActionSet(action.Read, action.AuthorizeSession), action.NewActionSet(action.Create, action.List))
+	action.RegisterResource(resource.Scope, action.NewActionSet(action.Read, action.Update), action.NewActionSet(action.List))
+
+	cases := []struct {
+		name string
+		res  resource.Type
+		act  action.Type
+		want bool
+	}{
+		{"TargetAuthorizeSession", resource.Target, action.AuthorizeSession, true},
+		{"TargetCollectionAction", resource.Target, action.List, true},
+		{"TargetDelete", resource.Target, action.Delete, false},
+		{"ScopeAuthorizeSession", resource.Scope, action.AuthorizeSession, false},
+		{"ScopeRead", resource.Scope, action.Read, true},
+		{"NotRegistered", resource.Alias, action.Read, false},
+		{"UnknownResource", resource.Unknown, action.Read, false},
+		{"UnknownAction", resource.Target, action.Unknown, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, action.SupportsAction(tc.res, tc.act))
+		})
+	}
+}
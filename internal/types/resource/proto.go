@@ -0,0 +1,67 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package resource
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/boundary/internal/errors"
+	pbs "github.com/hashicorp/boundary/internal/gen/controller/api/services"
+)
+
+// protoTypes is the canonical mapping between the protobuf resource types
+// and the domain layer resource types.
+var protoTypes = map[pbs.ResourceType]Type{
+	pbs.ResourceType_RESOURCE_TYPE_ACCOUNT:            Account,
+	pbs.ResourceType_RESOURCE_TYPE_ALIAS:              Alias,
+	pbs.ResourceType_RESOURCE_TYPE_AUTH_METHOD:        AuthMethod,
+	pbs.ResourceType_RESOURCE_TYPE_AUTH_TOKEN:         AuthToken,
+	pbs.ResourceType_RESOURCE_TYPE_CREDENTIAL_LIBRARY: CredentialLibrary,
+	pbs.ResourceType_RESOURCE_TYPE_CREDENTIAL_STORE:   CredentialStore,
+	pbs.ResourceType_RESOURCE_TYPE_CREDENTIAL:         Credential,
+	pbs.ResourceType_RESOURCE_TYPE_GROUP:              Group,
+	pbs.ResourceType_RESOURCE_TYPE_HOST_CATALOG:       HostCatalog,
+	pbs.ResourceType_RESOURCE_TYPE_HOST_SET:           HostSet,
+	pbs.ResourceType_RESOURCE_TYPE_HOST:               Host,
+	pbs.ResourceType_RESOURCE_TYPE_MANAGED_GROUP:      ManagedGroup,
+	pbs.ResourceType_RESOURCE_TYPE_ROLE:               Role,
+	pbs.ResourceType_RESOURCE_TYPE_SCOPE:              Scope,
+	pbs.ResourceType_RESOURCE_TYPE_SESSION_RECORDING:  SessionRecording,
+	pbs.ResourceType_RESOURCE_TYPE_SESSION:            Session,
+	pbs.ResourceType_RESOURCE_TYPE_STORAGE_BUCKET:     StorageBucket,
+	pbs.ResourceType_RESOURCE_TYPE_TARGET:             Target,
+	pbs.ResourceType_RESOURCE_TYPE_USER:               User,
+	pbs.ResourceType_RESOURCE_TYPE_WORKER:             Worker,
+	pbs.ResourceType_RESOURCE_TYPE_POLICY:             Policy,
+}
+
+// typeProtos is the inverse of protoTypes.
+var typeProtos = func() map[Type]pbs.ResourceType {
+	ret := make(map[Type]pbs.ResourceType, len(protoTypes))
+	for pt, t := range protoTypes {
+		ret[t] = pt
+	}
+	return ret
+}()
+
+// Proto returns the protobuf resource type of the type. Types which have no
+// protobuf equivalent return RESOURCE_TYPE_UNSPECIFIED.
+func (r Type) Proto() pbs.ResourceType {
+	if pt, ok := typeProtos[r]; ok {
+		return pt
+	}
+	return pbs.ResourceType_RESOURCE_TYPE_UNSPECIFIED
+}
+
+// FromProto returns the type of the provided protobuf resource type. An error
+// is returned if the value isn't mapped to a type.
+func FromProto(pt pbs.ResourceType) (Type, error) {
+	const op = "resource.FromProto"
+	t, ok := protoTypes[pt]
+	if !ok {
+		return Unknown, errors.New(context.TODO(), errors.InvalidParameter, op, fmt.Sprintf("unmapped protobuf resource type %s", pt), errors.WithoutEvent())
+	}
+	return t, nil
+}
@@ -0,0 +1,48 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package resource
+
+import (
+	"testing"
+
+	"github.com/hashicorp/boundary/internal/errors"
+	pbs "github.com/hashicorp/boundary/internal/gen/controller/api/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestType_ProtoRoundTrip(t *testing.T) {
+	// These types are never listed and so have no protobuf resource type.
+	unmapped := NewSet(Controller, Billing)
+
+	for _, typ := range AllTypes() {
+		t.Run(typ.String(), func(t *testing.T) {
+			pt := typ.Proto()
+			if unmapped.Contains(typ) {
+				assert.Equal(t, pbs.ResourceType_RESOURCE_TYPE_UNSPECIFIED, pt)
+				return
+			}
+			got, err := FromProto(pt)
+			require.NoError(t, err)
+			assert.Equal(t, typ, got)
+		})
+	}
+	for v := range pbs.ResourceType_name {
+		pt := pbs.ResourceType(v)
+		t.Run(pt.String(), func(t *testing.T) {
+			typ, err := FromProto(pt)
+			if pt == pbs.ResourceType_RESOURCE_TYPE_UNSPECIFIED {
+				assert.Truef(t, errors.Match(errors.T(errors.InvalidParameter), err), "unexpected error: %v", err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, pt, typ.Proto())
+		})
+	}
+
+	_, err := FromProto(pbs.ResourceType(1000))
+	assert.Truef(t, errors.Match(errors.T(errors.InvalidParameter), err), "unexpected error: %v", err)
+	assert.Equal(t, pbs.ResourceType_RESOURCE_TYPE_UNSPECIFIED, Unknown.Proto())
+	assert.Equal(t, pbs.ResourceType_RESOURCE_TYPE_UNSPECIFIED, All.Proto())
+}
@@ -4,8 +4,13 @@
 package resource
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"slices"
 	"strings"
+
+	"github.com/hashicorp/boundary/internal/errors"
 )
 
 // Type defines the types of resources in the system
@@ -39,11 +44,12 @@ const (
 	Alias
 	// NOTE: When adding a new type, be sure to update:
 	//
-	// * The Grant.validateType function and test
-	// * The perms.topLevelType function
+	// * The IsGrantable function and test
+	// * The topLevelTypes map
 	// * The scopes service collection actions for appropriate scopes
-	// * The Test_AnonRestrictions test: update the following line to include the last resource:
-	//      for i := resource.Type(1); i <= resource.<Resource>; i++ {
+	// * The AllTypes function
+	// * The descriptions map
+	// * The allowed scopes in the scope package
 	// * The prefixes and mappings in globals/prefixes.go
 )
 
@@ -51,6 +57,40 @@ func (r Type) MarshalJSON() ([]byte, error) {
 	return json.Marshal(r.String())
 }
 
+// UnmarshalJSON decodes a JSON string containing the String() form of a Type.
+// Unknown values and non-string JSON are rejected.
+func (r *Type) UnmarshalJSON(data []byte) error {
+	const op = "resource.(Type).UnmarshalJSON"
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return errors.Wrap(context.TODO(), err, op, errors.WithCode(errors.InvalidParameter), errors.WithoutEvent())
+	}
+	t, err := Parse(s)
+	if err != nil {
+		return errors.Wrap(context.TODO(), err, op, errors.WithoutEvent())
+	}
+	*r = t
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler, which allows a Type to be used
+// as a map key in JSON and in YAML documents.
+func (r Type) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. Unknown values are
+// rejected.
+func (r *Type) UnmarshalText(text []byte) error {
+	const op = "resource.(Type).UnmarshalText"
+	t, err := Parse(string(text))
+	if err != nil {
+		return errors.Wrap(context.TODO(), err, op, errors.WithoutEvent())
+	}
+	*r = t
+	return nil
+}
+
 func (r Type) String() string {
 	return [...]string{
 		"unknown",
@@ -81,35 +121,87 @@ func (r Type) String() string {
 	}[r]
 }
 
+// irregularPlurals contains the plural form of every type whose plural isn't
+// formed by simply appending an "s"
+var irregularPlurals = map[Type]string{
+	CredentialLibrary: "credential-libraries",
+	Policy:            "policies",
+	Billing:           "billing", // never pluralized
+	Alias:             "aliases",
+}
+
 func (r Type) PluralString() string {
-	switch r {
-	case CredentialLibrary:
-		return "credential-libraries"
-	case Policy:
-		return "policies"
-	case Billing: // never pluralized
-		return "billing"
-	case Alias:
-		return "aliases"
-	default:
-		return r.String() + "s"
+	if p, ok := irregularPlurals[r]; ok {
+		return p
 	}
+	return r.String() + "s"
+}
+
+// descriptions contains a human-friendly, one-line description of every type
+// other than Unknown
+var descriptions = map[Type]string{
+	All:               "Any type of resource",
+	Scope:             "A container for resources and the permissions to them",
+	User:              "A principal who can authenticate and be granted permissions",
+	Group:             "A collection of users who are granted permissions together",
+	Role:              "A set of grants given to the principals assigned to it",
+	AuthMethod:        "A means of authenticating users",
+	Account:           "A user's credentials within an auth method",
+	AuthToken:         "A token issued to a user after authenticating",
+	HostCatalog:       "A collection of hosts and host sets",
+	HostSet:           "A group of hosts within a host catalog",
+	Host:              "A network address which can be reached by a target",
+	Target:            "A network endpoint users connect to",
+	Controller:        "A server which handles API requests and coordinates workers",
+	Worker:            "A server which proxies session traffic",
+	Session:           "A connection made by a user to a target",
+	SessionRecording:  "A recording of the traffic of a session",
+	ManagedGroup:      "A group whose membership is defined by an auth method",
+	CredentialStore:   "A source of credentials for targets",
+	CredentialLibrary: "A means of issuing credentials from a credential store",
+	Credential:        "A secret used to authenticate to a target",
+	StorageBucket:     "A place where session recordings are stored",
+	Policy:            "A set of rules applied to resources, such as storage retention",
+	Billing:           "Usage information used for billing",
+	Alias:             "An alternative name used to reference a target",
+}
+
+// Description returns a human-friendly, one-line description of the type, for
+// use by CLIs and generated documentation. Unknown returns an empty string.
+func (r Type) Description() string {
+	return descriptions[r]
 }
 
 func FromPlural(s string) (Type, bool) {
-	switch s {
-	case "credential-libraries":
-		return CredentialLibrary, true
-	case "policies":
-		return Policy, true
-	case "billing":
-		return Billing, true
-	case "aliases":
-		return Alias, true
-	default:
-		t, ok := Map[strings.TrimSuffix(s, "s")]
-		return t, ok
+	for t, p := range irregularPlurals {
+		if p == s {
+			return t, true
+		}
+	}
+	t, ok := Map[strings.TrimSuffix(s, "s")]
+	return t, ok
+}
+
+// pluralMap maps the PluralString form of every concrete type back to its Type
+var pluralMap = func() map[string]Type {
+	ret := make(map[string]Type)
+	for _, t := range AllTypes() {
+		ret[t.PluralString()] = t
+	}
+	return ret
+}()
+
+// ParsePlural returns the Type whose PluralString matches the provided
+// string, e.g. "credential-libraries" returns CredentialLibrary. Unlike
+// FromPlural, singular forms are not accepted and an unrecognized string
+// results in an InvalidParameter error.
+func ParsePlural(s string) (Type, error) {
+	const op = "resource.ParsePlural"
+	t, ok := pluralMap[s]
+	if !ok {
+		return Unknown, errors.New(context.TODO(), errors.InvalidParameter, op, fmt.Sprintf("unknown plural resource type %q", s), errors.WithoutEvent())
 	}
+	return t, nil
 }
 
 var Map = map[string]Type{
@@ -140,6 +232,115 @@ var Map = map[string]Type{
 	Alias.String():             Alias,
 }
 
+// AllTypes returns every concrete resource type, excluding Unknown and All, in
+// declaration order.
+func AllTypes() []Type {
+	return []Type{
+		Scope,
+		User,
+		Group,
+		Role,
+		AuthMethod,
+		Account,
+		AuthToken,
+		HostCatalog,
+		HostSet,
+		Host,
+		Target,
+		Controller,
+		Worker,
+		Session,
+		SessionRecording,
+		ManagedGroup,
+		CredentialStore,
+		CredentialLibrary,
+		Credential,
+		StorageBucket,
+		Policy,
+		Billing,
+		Alias,
+	}
+}
+
+// JSONSchemaEnum returns the String form of every concrete resource type, in
+// the same order as AllTypes, for use as the "enum" of a JSON schema or OpenAPI
+// definition. Unknown and All are not included.
+func JSONSchemaEnum() []string {
+	all := AllTypes()
+	ret := make([]string, 0, len(all))
+	for _, t := range all {
+		ret = append(ret, t.String())
+	}
+	return ret
+}
+
+// Types calls yield for All and then for every concrete resource type, in the
+// same order as AllTypes, e.g.:
+//
+//	resource.Types(func(t resource.Type) bool {
+//		fmt.Println(t)
+//		return true
+//	})
+//
+// Iteration stops early if yield returns false. Its signature matches a
+// range-over-func iterator so it can be ranged over once the module requires a
+// Go version which supports that.
+func Types(yield func(Type) bool) {
+	if !yield(All) {
+		return
+	}
+	for _, t := range AllTypes() {
+		if !yield(t) {
+			return
+		}
+	}
+}
+
+// prefixes contains the known public ID prefixes for each type. The prefixes
+// are owned by the globals package, which registers them here to avoid an
+// import loop.
+var prefixes = make(map[Type][]string)
+
+// RegisterPrefixes records the provided public ID prefixes as belonging to the
+// type. It is only meant to be called by the globals package during its
+// initialization and panics if prefixes were already registered for the type.
+func RegisterPrefixes(typ Type, prefix ...string) {
+	if _, ok := prefixes[typ]; ok {
+		panic(fmt.Sprintf("prefixes already registered for resource type %s", typ))
+	}
+	prefixes[typ] = slices.Clone(prefix)
+}
+
+// Prefixes returns the known public ID prefixes for the type; if the type has
+// no prefixes the return value will be nil. The prefixes are registered by the
+// globals package when it is initialized, so callers must import it, directly
+// or indirectly, for any prefixes to be returned.
+func (r Type) Prefixes() []string {
+	return slices.Clone(prefixes[r])
+}
+
+// Parse returns the Type matching the provided string. Unlike Map, an
+// unrecognized string results in an InvalidParameter error rather than
+// Unknown. The lookup is case-sensitive.
+func Parse(s string) (Type, error) {
+	const op = "resource.Parse"
+	t, ok := Map[s]
+	if !ok {
+		return Unknown, errors.New(context.TODO(), errors.InvalidParameter, op, fmt.Sprintf("unknown resource type %q", s), errors.WithoutEvent())
+	}
+	return t, nil
+}
+
+// ParseInsensitive is like Parse but ignores the case of the provided string.
+func ParseInsensitive(s string) (Type, error) {
+	const op = "resource.ParseInsensitive"
+	t, err := Parse(strings.ToLower(s))
+	if err != nil {
+		return Unknown, errors.Wrap(context.TODO(), err, op, errors.WithoutEvent())
+	}
+	return t, nil
+}
+
 // Parent returns the parent type for a given type; if there is no parent, it
 // returns the incoming type
 func Parent(in Type) Type {
@@ -154,6 +355,25 @@ func Parent(in Type) Type {
 	return in
 }
 
+// AncestorChain returns the chain of parent types for a given type, starting
+// with its immediate parent and ending with its top-level parent. The incoming
+// type is not included, so a type without a parent returns an empty slice.
+func AncestorChain(in Type) []Type {
+	ret := []Type{}
+	cur := in
+	// Parent returns its input when there is no parent, so stop once the type
+	// stabilizes. The length check guards against a cycle in Parent.
+	for len(ret) < len(Map) {
+		p := Parent(cur)
+		if p == cur {
+			break
+		}
+		ret = append(ret, p)
+		cur = p
+	}
+	return ret
+}
+
 // HasChildTypes indicates whether this is a type that has child resource types;
 // it's essentially the inverse of Parent
 func HasChildTypes(in Type) bool {
@@ -164,26 +384,140 @@ func HasChildTypes(in Type) bool {
 	return false
 }
 
+// Children returns the child resource types for a given type; it's the inverse
+// of Parent. If the type has no children an empty slice is returned.
+func Children(in Type) []Type {
+	switch in {
+	case AuthMethod:
+		return []Type{Account, ManagedGroup}
+	case HostCatalog:
+		return []Type{HostSet, Host}
+	case CredentialStore:
+		return []Type{CredentialLibrary, Credential}
+	}
+	return []Type{}
+}
+
+// relatedTypes contains the types each type has a soft relationship with, i.e.
+// a relationship which doesn't make them a parent or child, such as the target
+// a session was created for.
+var relatedTypes = map[Type][]Type{
+	Session: {Target, User, Scope},
+}
+
+// RelatedTypes returns the types this type has a soft relationship with, for
+// building views across resources. Unlike Parent, these don't imply ownership.
+// If the type has no relations nil is returned.
+func (r Type) RelatedTypes() []Type {
+	rel, ok := relatedTypes[r]
+	if !ok {
+		return nil
+	}
+	return slices.Clone(rel)
+}
+
+// topLevelTypes contains the types that support collection actions
+var topLevelTypes = map[Type]bool{
+	AuthMethod:       true,
+	AuthToken:        true,
+	CredentialStore:  true,
+	Group:            true,
+	HostCatalog:      true,
+	Role:             true,
+	Scope:            true,
+	Session:          true,
+	SessionRecording: true,
+	Target:           true,
+	User:             true,
+	StorageBucket:    true,
+	Policy:           true,
+	Alias:            true,
+	Worker:           true,
+}
+
 // TopLevelType indicates whether this is a type that supports collection
 // actions, e.g. Create/List
 func TopLevelType(typ Type) bool {
-	switch typ {
-	case AuthMethod,
-		AuthToken,
-		CredentialStore,
-		Group,
-		HostCatalog,
-		Role,
-		Scope,
-		Session,
-		SessionRecording,
-		Target,
-		User,
-		StorageBucket,
-		Policy,
-		Alias,
-		Worker:
+	return topLevelTypes[typ]
+}
+
+// TopLevelTypes returns all types that support collection actions, sorted in
+// declaration order
+func TopLevelTypes() []Type {
+	ret := make([]Type, 0, len(topLevelTypes))
+	for t := range topLevelTypes {
+		ret = append(ret, t)
+	}
+	slices.Sort(ret)
+	return ret
+}
+
+// IsGrantable indicates whether the type may be used as the type of a grant,
+// e.g. "type=target". All is grantable as the "*" type; Unknown and any value
+// which isn't a defined type are not.
+func (r Type) IsGrantable() bool {
+	switch r {
+	case Unknown, Controller:
+		return false
+	case All:
 		return true
 	}
-	return false
+	return slices.Contains(AllTypes(), r)
+}
+
+// Set is a collection of distinct resource types. A nil Set can be read from
+// but not added to; use NewSet to create one.
+type Set map[Type]struct{}
+
+// NewSet returns a Set containing the provided types.
+func NewSet(t ...Type) Set {
+	s := make(Set, len(t))
+	s.Add(t...)
+	return s
+}
+
+// Add adds the provided types to the set.
+func (s Set) Add(t ...Type) {
+	for _, typ := range t {
+		s[typ] = struct{}{}
+	}
+}
+
+// Contains indicates whether the provided type is in the set.
+func (s Set) Contains(t Type) bool {
+	_, ok := s[t]
+	return ok
+}
+
+// Union returns a new set containing the types found in either set.
+func (s Set) Union(o Set) Set {
+	ret := make(Set, len(s)+len(o))
+	for t := range s {
+		ret[t] = struct{}{}
+	}
+	for t := range o {
+		ret[t] = struct{}{}
+	}
+	return ret
+}
+
+// Intersect returns a new set containing the types found in both sets.
+func (s Set) Intersect(o Set) Set {
+	ret := make(Set)
+	for t := range s {
+		if o.Contains(t) {
+			ret[t] = struct{}{}
+		}
+	}
+	return ret
+}
+
+// Slice returns the types in the set sorted in declaration order.
+func (s Set) Slice() []Type {
+	ret := make([]Type, 0, len(s))
+	for t := range s {
+		ret = append(ret, t)
+	}
+	slices.Sort(ret)
+	return ret
 }
@@ -0,0 +1,38 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package resource_test
+
+import (
+	"testing"
+
+	"github.com/hashicorp/boundary/globals"
+	"github.com/hashicorp/boundary/internal/types/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestType_Prefixes(t *testing.T) {
+	assert.ElementsMatch(t, []string{globals.TcpTargetPrefix, globals.SshTargetPrefix}, resource.Target.Prefixes())
+	assert.ElementsMatch(t, []string{globals.GlobalPrefix, globals.OrgPrefix, globals.ProjectPrefix}, resource.Scope.Prefixes())
+	assert.Nil(t, resource.Unknown.Prefixes())
+	assert.Nil(t, resource.Controller.Prefixes())
+
+	for _, typ := range resource.AllTypes() {
+		assert.ElementsMatchf(t, globals.ResourcePrefixesFromType(typ), typ.Prefixes(), "unexpected prefixes for %s", typ)
+	}
+}
+
+func TestType_PrefixesCopy(t *testing.T) {
+	p := resource.Target.Prefixes()
+	require.NotEmpty(t, p)
+	p[0] = "modified"
+	assert.NotContains(t, resource.Target.Prefixes(), "modified")
+}
+
+func TestRegisterPrefixes_Duplicate(t *testing.T) {
+	assert.Panics(t, func() {
+		resource.RegisterPrefixes(resource.Target, "dup")
+	})
+	assert.NotContains(t, resource.Target.Prefixes(), "dup")
+}
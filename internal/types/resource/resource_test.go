@@ -4,9 +4,14 @@
 package resource
 
 import (
+	"encoding/json"
+	"slices"
+	"strings"
 	"testing"
 
+	"github.com/hashicorp/boundary/internal/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_Resource(t *testing.T) {
@@ -148,3 +153,371 @@ func Test_Resource(t *testing.T) {
 		})
 	}
 }
+
+func Test_Parse(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    Type
+		wantErr bool
+	}{
+		{
+			name:    "empty",
+			in:      "",
+			wantErr: true,
+		},
+		{
+			name: "target",
+			in:   "target",
+			want: Target,
+		},
+		{
+			name:    "plural",
+			in:      "credential-libraries",
+			wantErr: true,
+		},
+		{
+			name:    "unknown value",
+			in:      "not-a-resource",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.in)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.True(t, errors.Match(errors.T(errors.InvalidParameter), err))
+				assert.Equal(t, Unknown, got)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_JSONRoundTrip(t *testing.T) {
+	for _, typ := range Map {
+		t.Run(typ.String(), func(t *testing.T) {
+			b, err := json.Marshal(typ)
+			require.NoError(t, err)
+			var got Type
+			require.NoError(t, json.Unmarshal(b, &got))
+			assert.Equal(t, typ, got)
+		})
+	}
+	t.Run("special spellings", func(t *testing.T) {
+		var got Type
+		require.NoError(t, json.Unmarshal([]byte(`"*"`), &got))
+		assert.Equal(t, All, got)
+		require.NoError(t, json.Unmarshal([]byte(`"unknown"`), &got))
+		assert.Equal(t, Unknown, got)
+	})
+	t.Run("unknown value", func(t *testing.T) {
+		var got Type
+		err := json.Unmarshal([]byte(`"not-a-resource"`), &got)
+		require.Error(t, err)
+		assert.True(t, errors.Match(errors.T(errors.InvalidParameter), err))
+	})
+	t.Run("numeric", func(t *testing.T) {
+		var got Type
+		err := json.Unmarshal([]byte(`12`), &got)
+		require.Error(t, err)
+		assert.True(t, errors.Match(errors.T(errors.InvalidParameter), err))
+	})
+}
+
+func Test_TextRoundTrip(t *testing.T) {
+	in := map[Type]int{
+		Target:            1,
+		CredentialLibrary: 2,
+		All:               3,
+	}
+	b, err := json.Marshal(in)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"target":1,"credential-library":2,"*":3}`, string(b))
+
+	var got map[Type]int
+	require.NoError(t, json.Unmarshal(b, &got))
+	assert.Equal(t, in, got)
+
+	var typ Type
+	err = typ.UnmarshalText([]byte("not-a-resource"))
+	require.Error(t, err)
+	assert.True(t, errors.Match(errors.T(errors.InvalidParameter), err))
+}
+
+func Test_AllTypes(t *testing.T) {
+	all := AllTypes()
+	// Map contains every constant, including Unknown and All
+	assert.Len(t, all, len(Map)-2)
+	assert.NotContains(t, all, Unknown)
+	assert.NotContains(t, all, All)
+	for i := 1; i < len(all); i++ {
+		assert.Less(t, all[i-1], all[i], "types are not in declaration order")
+	}
+}
+
+func Test_Children(t *testing.T) {
+	assert.Equal(t, []Type{Account, ManagedGroup}, Children(AuthMethod))
+	assert.Equal(t, []Type{HostSet, Host}, Children(HostCatalog))
+	assert.Equal(t, []Type{CredentialLibrary, Credential}, Children(CredentialStore))
+	assert.Empty(t, Children(Target))
+	assert.NotNil(t, Children(Target))
+
+	for _, typ := range AllTypes() {
+		children := Children(typ)
+		assert.Equalf(t, HasChildTypes(typ), len(children) > 0, "unexpected children for %s", typ)
+		for _, c := range children {
+			assert.Equalf(t, typ, Parent(c), "unexpected parent for child %s of %s", c, typ)
+		}
+	}
+}
+
+func Test_AncestorChain(t *testing.T) {
+	tests := []struct {
+		name string
+		in   Type
+		want []Type
+	}{
+		{
+			name: "leaf",
+			in:   CredentialLibrary,
+			want: []Type{CredentialStore},
+		},
+		{
+			name: "leaf of auth method",
+			in:   ManagedGroup,
+			want: []Type{AuthMethod},
+		},
+		{
+			name: "type with children",
+			in:   HostCatalog,
+			want: []Type{},
+		},
+		{
+			name: "top-level",
+			in:   Scope,
+			want: []Type{},
+		},
+		{
+			name: "unknown",
+			in:   Unknown,
+			want: []Type{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, AncestorChain(tt.in))
+		})
+	}
+}
+
+func Test_ParseInsensitive(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Type
+		wantErr bool
+	}{
+		{in: "Target", want: Target},
+		{in: "AUTH-METHOD", want: AuthMethod},
+		{in: "Credential-Library", want: CredentialLibrary},
+		{in: "sEsSiOn-ReCoRdInG", want: SessionRecording},
+		{in: "*", want: All},
+		{in: "Not-A-Resource", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseInsensitive(tt.in)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.True(t, errors.Match(errors.T(errors.InvalidParameter), err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_ParsePlural(t *testing.T) {
+	for _, typ := range AllTypes() {
+		t.Run(typ.PluralString(), func(t *testing.T) {
+			got, err := ParsePlural(typ.PluralString())
+			require.NoError(t, err)
+			assert.Equal(t, typ, got)
+		})
+	}
+	t.Run("credential libraries", func(t *testing.T) {
+		got, err := ParsePlural("credential-libraries")
+		require.NoError(t, err)
+		assert.Equal(t, CredentialLibrary, got)
+	})
+	for _, in := range []string{"", "target", "credential-librarys", "unknowns", "not-a-resources"} {
+		t.Run("invalid "+in, func(t *testing.T) {
+			got, err := ParsePlural(in)
+			require.Error(t, err)
+			assert.True(t, errors.Match(errors.T(errors.InvalidParameter), err))
+			assert.Equal(t, Unknown, got)
+		})
+	}
+}
+
+func Test_Alias(t *testing.T) {
+	assert.Equal(t, Alias, Map["alias"])
+	assert.Equal(t, "aliases", Alias.PluralString())
+	assert.True(t, TopLevelType(Alias))
+	assert.Equal(t, Alias, Parent(Alias))
+
+	b, err := json.Marshal(Alias)
+	require.NoError(t, err)
+	assert.Equal(t, `"alias"`, string(b))
+	var got Type
+	require.NoError(t, json.Unmarshal(b, &got))
+	assert.Equal(t, Alias, got)
+}
+
+func Test_Policy(t *testing.T) {
+	assert.Equal(t, Policy, Map["policy"])
+	assert.Equal(t, "policies", Policy.PluralString())
+	assert.True(t, TopLevelType(Policy))
+	assert.Equal(t, Policy, Parent(Policy))
+
+	b, err := json.Marshal(Policy)
+	require.NoError(t, err)
+	assert.Equal(t, `"policy"`, string(b))
+	var got Type
+	require.NoError(t, json.Unmarshal(b, &got))
+	assert.Equal(t, Policy, got)
+}
+
+func Test_PluralString(t *testing.T) {
+	irregular := map[Type]string{
+		CredentialLibrary: "credential-libraries",
+		Policy:            "policies",
+		Billing:           "billing",
+		Alias:             "aliases",
+	}
+	for _, typ := range AllTypes() {
+		t.Run(typ.String(), func(t *testing.T) {
+			plural := typ.PluralString()
+			require.NotEmpty(t, plural)
+			assert.False(t, strings.HasSuffix(plural, "ss"), "plural %q has a double s", plural)
+			assert.False(t, strings.HasSuffix(plural, "ys"), "plural %q should end in ies", plural)
+			want, ok := irregular[typ]
+			if !ok {
+				want = typ.String() + "s"
+			}
+			assert.Equal(t, want, plural)
+
+			got, ok := FromPlural(plural)
+			assert.True(t, ok)
+			assert.Equal(t, typ, got)
+		})
+	}
+}
+
+func Test_Description(t *testing.T) {
+	assert.Empty(t, Unknown.Description())
+	assert.Equal(t, "A network endpoint users connect to", Target.Description())
+	for _, typ := range append([]Type{All}, AllTypes()...) {
+		assert.NotEmptyf(t, typ.Description(), "missing description for %s", typ)
+	}
+}
+
+func Test_Types(t *testing.T) {
+	t.Run("all", func(t *testing.T) {
+		var got []Type
+		Types(func(typ Type) bool {
+			got = append(got, typ)
+			return true
+		})
+		require.NotEmpty(t, got)
+		assert.Equal(t, All, got[0])
+		assert.Equal(t, AllTypes(), got[1:])
+	})
+	t.Run("early break", func(t *testing.T) {
+		var got []Type
+		Types(func(typ Type) bool {
+			got = append(got, typ)
+			return typ != User
+		})
+		assert.Equal(t, []Type{All, Scope, User}, got)
+	})
+}
+
+func Test_TopLevelTypes(t *testing.T) {
+	tlts := TopLevelTypes()
+	assert.IsIncreasing(t, tlts)
+	for _, typ := range append([]Type{Unknown, All}, AllTypes()...) {
+		assert.Equalf(t, TopLevelType(typ), slices.Contains(tlts, typ), "mismatch for %s", typ)
+	}
+}
+
+func Test_RelatedTypes(t *testing.T) {
+	assert.Equal(t, []Type{Target, User, Scope}, Session.RelatedTypes())
+	assert.Nil(t, Credential.RelatedTypes())
+	assert.Nil(t, Unknown.RelatedTypes())
+
+	// The returned slice can't be used to modify the relations.
+	Session.RelatedTypes()[0] = Host
+	assert.Equal(t, []Type{Target, User, Scope}, Session.RelatedTypes())
+}
+
+func Test_IsGrantable(t *testing.T) {
+	notGrantable := []Type{Unknown, Controller}
+	for _, typ := range append([]Type{Unknown, All}, AllTypes()...) {
+		assert.Equalf(t, !slices.Contains(notGrantable, typ), typ.IsGrantable(), "mismatch for %s", typ)
+	}
+	assert.False(t, Type(len(Map)).IsGrantable(), "undefined type")
+}
+
+func TestSet(t *testing.T) {
+	t.Run("add and contains", func(t *testing.T) {
+		s := NewSet(Target)
+		s.Add(Host, Target)
+		assert.Len(t, s, 2)
+		assert.True(t, s.Contains(Target))
+		assert.True(t, s.Contains(Host))
+		assert.False(t, s.Contains(Scope))
+	})
+	t.Run("union", func(t *testing.T) {
+		a, b := NewSet(Target, Host), NewSet(Host, Scope)
+		assert.Equal(t, []Type{Scope, Host, Target}, a.Union(b).Slice())
+		assert.Equal(t, []Type{Host, Target}, a.Slice(), "union must not modify the receiver")
+		assert.Equal(t, []Type{Host, Target}, a.Union(nil).Slice())
+	})
+	t.Run("intersect", func(t *testing.T) {
+		a, b := NewSet(Target, Host, Role), NewSet(Host, Scope, Role)
+		assert.Equal(t, []Type{Role, Host}, a.Intersect(b).Slice())
+		assert.Empty(t, a.Intersect(NewSet(Alias)).Slice())
+		assert.Empty(t, a.Intersect(nil).Slice())
+	})
+	t.Run("slice is sorted", func(t *testing.T) {
+		all := AllTypes()
+		s := NewSet()
+		for i := len(all) - 1; i >= 0; i-- {
+			s.Add(all[i])
+		}
+		for i := 0; i < 10; i++ {
+			assert.Equal(t, all, s.Slice())
+		}
+		assert.Empty(t, NewSet().Slice())
+	})
+}
+
+func TestJSONSchemaEnum(t *testing.T) {
+	got := JSONSchemaEnum()
+	all := AllTypes()
+	require.Len(t, got, len(all))
+	for i, typ := range all {
+		assert.Equal(t, typ.String(), got[i])
+		parsed, err := Parse(got[i])
+		require.NoError(t, err)
+		assert.Equal(t, typ, parsed)
+	}
+	assert.NotContains(t, got, Unknown.String())
+	assert.NotContains(t, got, All.String())
+	assert.Equal(t, got, JSONSchemaEnum(), "ordering must be stable")
+}
@@ -3,7 +3,10 @@
 
 package scope
 
-import "github.com/hashicorp/boundary/globals"
+import (
+	"github.com/hashicorp/boundary/globals"
+	"github.com/hashicorp/boundary/internal/types/resource"
+)
 
 // Type defines the possible types for Scopes
 type Type uint
@@ -38,3 +41,50 @@ var Map = map[string]Type{
 	Org.String():     Org,
 	Project.String(): Project,
 }
+
+// allowedScopes contains the scope types in which resources of each type can
+// be created. It lives here rather than in the resource package since that
+// package cannot import this one without creating an import loop.
+var allowedScopes = map[resource.Type][]Type{
+	resource.Scope:             {Global, Org},
+	resource.User:              {Global, Org},
+	resource.Group:             {Global, Org, Project},
+	resource.Role:              {Global, Org, Project},
+	resource.AuthMethod:        {Global, Org},
+	resource.Account:           {Global, Org},
+	resource.AuthToken:         {Global, Org},
+	resource.HostCatalog:       {Project},
+	resource.HostSet:           {Project},
+	resource.Host:              {Project},
+	resource.Target:            {Project},
+	resource.Controller:        {Global},
+	resource.Worker:            {Global},
+	resource.Session:           {Project},
+	resource.SessionRecording:  {Global, Org},
+	resource.ManagedGroup:      {Global, Org},
+	resource.CredentialStore:   {Project},
+	resource.CredentialLibrary: {Project},
+	resource.Credential:        {Project},
+	resource.StorageBucket:     {Global, Org},
+	resource.Policy:            {Global, Org},
+	resource.Billing:           {Global},
+	resource.Alias:             {Global},
+}
+
+// AllowedFor returns the scope types in which resources of the provided type
+// can be created, ordered from global to project. It returns nil for
+// resource.Unknown and resource.All.
+func AllowedFor(r resource.Type) []Type {
+	return append([]Type(nil), allowedScopes[r]...)
+}
+
+// Allows indicates whether resources of the provided type can be created in
+// a scope of this type.
+func (s Type) Allows(r resource.Type) bool {
+	for _, t := range allowedScopes[r] {
+		if t == s {
+			return true
+		}
+	}
+	return false
+}
@@ -4,9 +4,11 @@
 package scope
 
 import (
+	"slices"
 	"testing"
 
 	"github.com/hashicorp/boundary/globals"
+	"github.com/hashicorp/boundary/internal/types/resource"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -51,3 +53,34 @@ func Test_Map(t *testing.T) {
 		})
 	}
 }
+
+func Test_AllowedFor(t *testing.T) {
+	tests := []struct {
+		r    resource.Type
+		want []Type
+	}{
+		{r: resource.Unknown},
+		{r: resource.All},
+		{r: resource.Target, want: []Type{Project}},
+		{r: resource.HostCatalog, want: []Type{Project}},
+		{r: resource.AuthMethod, want: []Type{Global, Org}},
+		{r: resource.Role, want: []Type{Global, Org, Project}},
+		{r: resource.Worker, want: []Type{Global}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.r.String(), func(t *testing.T) {
+			assert := assert.New(t)
+			got := AllowedFor(tt.r)
+			assert.Equal(tt.want, got)
+			for _, s := range []Type{Unknown, Global, Org, Project} {
+				assert.Equalf(slices.Contains(tt.want, s), s.Allows(tt.r), "unexpected result for %s in %s", tt.r, s)
+			}
+		})
+	}
+
+	t.Run("every type", func(t *testing.T) {
+		for _, r := range resource.AllTypes() {
+			assert.NotEmptyf(t, AllowedFor(r), "no allowed scopes for %s", r)
+		}
+	})
+}